@@ -1,17 +1,24 @@
 package v1
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"os"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	log "github.com/sirupsen/logrus"
 	"github.com/srad/mediasink/app"
 	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/helpers"
 	"github.com/srad/mediasink/models/requests"
+	"github.com/srad/mediasink/resolvers"
 	"github.com/srad/mediasink/services"
 )
 
@@ -95,6 +102,162 @@ func GetChannel(c *gin.Context) {
 	}
 }
 
+// GetChannelHistory godoc
+// @Summary     Return the online history of a channel
+// @Description Return the online sessions with their recordings, the transition and resolver error events, and statistics derived from the sessions.
+// @Param       id path uint true "Channel id"
+// @Param       days query uint false "Number of days to look back, default 30"
+// @Tags        channels
+// @Produce     json
+// @Success     200 {object} services.ChannelHistory
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/sessions [get]
+func GetChannelHistory(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, fmt.Errorf("invalid id type: %s", err))
+		return
+	}
+
+	days, err := strconv.ParseUint(c.DefaultQuery("days", "30"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, fmt.Errorf("invalid days: %s", err))
+		return
+	}
+
+	history, err := services.GetChannelHistory(database.ChannelID(id), time.Now().AddDate(0, 0, -int(days)))
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, history)
+}
+
+// GetChannelItems godoc
+// @Summary     Return the episodes of a feed channel or the videos of an archive channel
+// @Description Return the items of the feed or archive with their title, description and publish date, the latest first. Items which have been downloaded reference their recording.
+// @Param       id path uint true "Channel id"
+// @Tags        channels
+// @Produce     json
+// @Success     200 {object} []database.ChannelItem
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/items [get]
+func GetChannelItems(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, fmt.Errorf("invalid id type: %s", err))
+		return
+	}
+
+	items, err := services.GetChannelItems(database.ChannelID(id))
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, items)
+}
+
+// GetCameraIndex godoc
+// @Summary     Return the recordings of a channel by day and hour
+// @Description Groups the finished recordings by the day and hour of their start in server time, i.e. to browse the footage of a camera.
+// @Param       id path uint true "Channel id"
+// @Param       day query string false "Only this day, YYYY-MM-DD"
+// @Tags        channels
+// @Produce     json
+// @Success     200 {object} []services.CameraDay
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/index [get]
+func GetCameraIndex(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, fmt.Errorf("invalid id type: %s", err))
+		return
+	}
+
+	days, err := services.CameraIndex(database.ChannelID(id), c.Query("day"))
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, days)
+}
+
+// GetLiveStream godoc
+// @Summary     Return the live HLS playlist of a channel which is being recorded
+// @Description Serves the playlist (index.m3u8) with the last segments of the running capture, and the segments. If the authorization is passed as query parameter, it is appended to the segment URIs of the playlist.
+// @Param       id path uint true "Channel id"
+// @Param       file path string true "index.m3u8 or a segment"
+// @Tags        channels
+// @Produce     application/vnd.apple.mpegurl
+// @Success     200
+// @Failure     400 {} string "Error message"
+// @Failure     404 {} string "Error message"
+// @Router      /channels/{id}/live/{file} [get]
+func GetLiveStream(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, fmt.Errorf("invalid id type: %s", err))
+		return
+	}
+
+	path, err := services.LiveFile(database.ChannelID(id), c.Param("file"))
+	serveHLS(c, path, err, services.ReadLivePlaylist)
+}
+
+// ClipChannel godoc
+// @Summary     Clip a range of a channel which is being recorded
+// @Description Enqueues a job which extracts a range of the running capture into a new recording, i.e. the last 5 minutes with {"duration": 300}. The capture continues.
+// @Param       id path uint true "Channel id"
+// @Param       ClipRequest body requests.ClipRequest true "Offsets in seconds before the live edge"
+// @Tags        channels
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} database.Job
+// @Failure     400 {} string "Error message"
+// @Failure     404 {} string "Error message"
+// @Router      /channels/{id}/clip [post]
+func ClipChannel(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, fmt.Errorf("invalid id type: %s", err))
+		return
+	}
+
+	var data requests.ClipRequest
+	if err := c.BindJSON(&data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := services.ClipChannel(database.ChannelID(id), data.Start, data.End, data.Duration)
+	if errors.Is(err, services.ErrNotLive) {
+		appG.Error(http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, job)
+}
+
 // CreateChannel godoc
 // @Summary     Add a new channel
 // @Description Add a new channel
@@ -118,7 +281,19 @@ func CreateChannel(c *gin.Context) {
 		return
 	}
 
-	if newChannel, err := services.CreateChannel(data.ChannelName, data.DisplayName, data.SkipStart, data.MinDuration, data.Url, data.Fav, data.Tags, data.IsPaused); err != nil {
+	if err := validateChannelRequest(data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	channel := channelFromRequest(0, data, nil)
+
+	if err := services.CheckIngestPort(&channel); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	if newChannel, err := services.CreateChannel(channel); err != nil {
 		appG.Error(http.StatusInternalServerError, err)
 		return
 	} else {
@@ -136,6 +311,7 @@ func CreateChannel(c *gin.Context) {
 // @Produce     json
 // @Success     200 {object} database.Channel
 // @Failure     400 {} http.StatusBadRequest
+// @Failure     404 {} http.StatusNotFound
 // @Failure     500 {} http.StatusInternalServerError
 // @Router      /channels/{id} [patch]
 func UpdateChannel(c *gin.Context) {
@@ -156,17 +332,26 @@ func UpdateChannel(c *gin.Context) {
 		return
 	}
 
-	channel := database.Channel{
-		ChannelID:   database.ChannelID(id),
-		ChannelName: database.ChannelName(data.ChannelName),
-		DisplayName: data.DisplayName,
-		SkipStart:   data.SkipStart,
-		MinDuration: data.MinDuration,
-		URL:         data.Url,
-		Tags:        data.Tags,
-		Fav:         data.Fav,
-		IsPaused:    data.IsPaused,
-		Deleted:     data.Deleted,
+	if err := validateChannelRequest(data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	stored, err := database.GetChannelByID(database.ChannelID(id))
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+	if stored == nil {
+		appG.Error(http.StatusNotFound, fmt.Errorf("channel %d not found", id))
+		return
+	}
+
+	channel := channelFromRequest(database.ChannelID(id), data, stored)
+
+	if err := services.CheckIngestPort(&channel); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
 	}
 
 	if err := channel.Update(); err != nil {
@@ -175,6 +360,7 @@ func UpdateChannel(c *gin.Context) {
 		appG.Error(http.StatusInternalServerError, message)
 		return
 	}
+	services.ResetPoll(channel.ChannelID)
 
 	if channel.IsPaused {
 		if err := services.TerminateProcess(channel.ChannelID); err != nil {
@@ -188,6 +374,140 @@ func UpdateChannel(c *gin.Context) {
 	appG.Response(http.StatusOK, &channel)
 }
 
+// channelFromRequest The stored channel is nil for new channels.
+func channelFromRequest(id database.ChannelID, data *requests.ChannelRequest, stored *database.Channel) database.Channel {
+	// Publishers need a key, the stored one is kept unless another one has been chosen.
+	streamKey := data.StreamKey
+	if streamKey == "" && stored != nil {
+		streamKey = stored.StreamKey
+	}
+	if data.Type == database.ChannelTypeIngest && streamKey == "" {
+		streamKey = database.NewStreamKey()
+	}
+
+	return database.Channel{
+		ChannelID:       id,
+		ChannelName:     database.ChannelName(data.ChannelName),
+		DisplayName:     data.DisplayName,
+		SkipStart:       data.SkipStart,
+		MinDuration:     data.MinDuration,
+		URL:             data.Url,
+		Tags:            data.Tags,
+		Fav:             data.Fav,
+		IsPaused:        data.IsPaused,
+		Deleted:         data.Deleted,
+		SegmentDuration: data.SegmentDuration,
+		SegmentSize:     data.SegmentSize,
+		ReconnectGrace:  data.ReconnectGrace,
+		AutoMerge:       data.AutoMerge,
+		Resolver:        data.Resolver,
+		FormatSelector:  data.FormatSelector,
+		ResolverScript:  data.ResolverScript,
+		MaxResolution:   data.MaxResolution,
+		Headers:         data.Headers,
+		UserAgent:       data.UserAgent,
+		CookiesFile:     data.CookiesFile,
+		Proxy:           data.Proxy,
+		AudioOnly:       data.AudioOnly,
+		AudioCodec:      data.AudioCodec,
+		Type:            data.Type,
+		SplitTracks:     data.SplitTracks,
+		IngestProtocol:  data.IngestProtocol,
+		IngestPort:      data.IngestPort,
+		StreamKey:       streamKey,
+		Backfill:        data.Backfill,
+		RingQuota:       data.RingQuota,
+		CaptureRules:    data.CaptureRules,
+		RetentionCount:  data.RetentionCount,
+		RetentionSize:   data.RetentionSize,
+		RetentionDays:   data.RetentionDays,
+		StallTimeout:    data.StallTimeout,
+
+		DisableStallWatchdog: data.DisableStallWatchdog,
+	}
+}
+
+func validateChannelRequest(data *requests.ChannelRequest) error {
+	if _, err := resolvers.Get(resolvers.Name(data.Resolver)); err != nil {
+		return err
+	}
+	if script := data.ResolverScript; script != "" && (script != filepath.Base(script) || script == "." || script == "..") {
+		return fmt.Errorf("resolver script '%s' must be a filename", data.ResolverScript)
+	}
+	if cookies := data.CookiesFile; cookies != "" && (cookies != filepath.Base(cookies) || cookies == "." || cookies == "..") {
+		return fmt.Errorf("cookies file '%s' must be a filename", data.CookiesFile)
+	}
+	if err := data.Type.IsValid(); err != nil {
+		return err
+	}
+	if err := data.CaptureRules.IsValid(); err != nil {
+		return err
+	}
+	// Cameras are recorded from their RTSP URL.
+	if data.Type == database.ChannelTypeCamera && resolvers.Name(data.Resolver) == resolvers.YtDlp {
+		return errors.New("camera channels can't be resolved by yt-dlp")
+	}
+	if data.Type == database.ChannelTypeFeed && !strings.HasPrefix(data.Url, "http://") && !strings.HasPrefix(data.Url, "https://") {
+		return fmt.Errorf("feed url '%s' must be an http(s) url", data.Url)
+	}
+	if data.Type == database.ChannelTypeArchive {
+		// Only yt-dlp enumerates playlists.
+		if name := resolvers.Name(data.Resolver); name != "" && name != resolvers.YtDlp {
+			return fmt.Errorf("archive channels can't be resolved by '%s'", data.Resolver)
+		}
+		if !strings.HasPrefix(data.Url, "http://") && !strings.HasPrefix(data.Url, "https://") {
+			return fmt.Errorf("archive url '%s' must be an http(s) url", data.Url)
+		}
+	}
+	if data.Type == database.ChannelTypeIngest {
+		if err := data.IngestProtocol.IsValid(); err != nil {
+			return err
+		}
+		if data.IngestPort < 1024 || data.IngestPort > 65535 {
+			return fmt.Errorf("ingest port %d must be within 1024 and 65535", data.IngestPort)
+		}
+		if data.StreamKey != "" {
+			if err := database.IsValidStreamKey(data.StreamKey); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := helpers.GetAudioFormat(data.AudioCodec); err != nil {
+		return err
+	}
+	if err := data.Headers.IsValid(); err != nil {
+		return err
+	}
+	if strings.ContainsAny(data.UserAgent, "\r\n") {
+		return errors.New("user agent must not contain line breaks")
+	}
+	if data.Proxy != "" {
+		proxy, err := url.Parse(data.Proxy)
+		if err != nil {
+			return fmt.Errorf("invalid proxy '%s': %w", data.Proxy, err)
+		}
+		switch proxy.Scheme {
+		case "http", "https", "socks4", "socks5", "socks5h":
+		default:
+			return fmt.Errorf("unsupported proxy scheme '%s'", proxy.Scheme)
+		}
+		// Streams and cameras are captured by ffmpeg, which only supports HTTP proxies. Radios and feeds
+		// are read by the server, only archives are downloaded entirely by yt-dlp.
+		switch data.Type {
+		case "", database.ChannelTypeStream, database.ChannelTypeCamera:
+			if proxy.Scheme != "http" {
+				return fmt.Errorf("proxy scheme '%s' is not supported by ffmpeg, use an http proxy", proxy.Scheme)
+			}
+		case database.ChannelTypeArchive:
+		default:
+			if proxy.Scheme == "socks4" {
+				return fmt.Errorf("proxy scheme '%s' is only supported by archive channels", proxy.Scheme)
+			}
+		}
+	}
+	return nil
+}
+
 // DeleteChannel godoc
 // @Summary     Delete channel
 // @Description Delete channel with all recordings
@@ -277,6 +597,7 @@ func ResumeChannel(c *gin.Context) {
 		appG.Error(http.StatusInternalServerError, err)
 		return
 	}
+	services.ResetPoll(channelID)
 
 	if _, err := services.Start(channelID); err != nil {
 		log.Errorf("[ResumeChannel] Error resuming channel-id %d: %s", channelID, err)
@@ -403,6 +724,42 @@ func UploadChannel(c *gin.Context) {
 	appG.Response(http.StatusOK, recording)
 }
 
+// FetchChannel godoc
+// @Summary     Download a video from a URL into the channel
+// @Description Enqueues a job which downloads the video of a page with yt-dlp or a media file directly. The file is validated and added as recording with previews.
+// @Tags        channels
+// @Param       id path uint true "Channel id"
+// @Param       FetchRequest body requests.FetchRequest true "Video page or media file"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} database.Job
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/fetch [post]
+func FetchChannel(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, fmt.Errorf("invalid id type: %s", err))
+		return
+	}
+
+	var data requests.FetchRequest
+	if err := c.BindJSON(&data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	job, err := services.FetchURL(database.ChannelID(id), strings.TrimSpace(data.URL))
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, job)
+}
+
 // PauseChannel godoc
 // @Summary     Pause channel for recording
 // @Description Pause channel for recording
@@ -432,6 +789,7 @@ func PauseChannel(c *gin.Context) {
 		appG.Error(http.StatusInternalServerError, err)
 		return
 	}
+	services.ResetPoll(channelID)
 
 	appG.Response(http.StatusOK, nil)
 }
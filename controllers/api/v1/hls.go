@@ -0,0 +1,55 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+
+	"github.com/gin-gonic/gin"
+	"github.com/srad/mediasink/app"
+	"github.com/srad/mediasink/helpers"
+	"github.com/srad/mediasink/services"
+)
+
+// serveHLS Responds with a playlist or segment of a capture. Players cannot send the authorization header
+// along with segment requests, so a token passed as query parameter is appended to the segment URIs.
+func serveHLS(c *gin.Context, path string, err error, readPlaylist func(path string) (string, error)) {
+	appG := app.Gin{C: c}
+
+	if err != nil {
+		if errors.Is(err, services.ErrNotLive) || os.IsNotExist(err) {
+			appG.Error(http.StatusNotFound, err)
+		} else {
+			appG.Error(http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	c.Header("Cache-Control", "no-cache")
+
+	if filepath.Ext(path) != ".m3u8" {
+		c.Header("Content-Type", "video/mp2t")
+		c.File(path)
+		return
+	}
+
+	playlist, err := readPlaylist(path)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	query := ""
+	if token := c.Query("Authorization"); token != "" {
+		query = url.Values{"Authorization": {token}}.Encode()
+	}
+
+	c.Data(http.StatusOK, "application/vnd.apple.mpegurl", []byte(helpers.AppendPlaylistQuery(playlist, query)))
+}
+
+func readPlaylist(path string) (string, error) {
+	playlist, err := os.ReadFile(path)
+	return string(playlist), err
+}
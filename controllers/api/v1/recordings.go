@@ -1,8 +1,10 @@
 package v1
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 
 	"github.com/srad/mediasink/helpers"
 	"github.com/srad/mediasink/models/requests"
@@ -24,7 +26,7 @@ import (
 // @Router      /recordings [get]
 func GetRecordings(c *gin.Context) {
 	appG := app.Gin{C: c}
-	recordings, err := database.RecordingsList()
+	recordings, err := database.RecordingsListByStatus(database.RecordingStatusReady)
 
 	if err != nil {
 		appG.Error(http.StatusInternalServerError, nil)
@@ -138,6 +140,116 @@ func GetBookmarks(c *gin.Context) {
 	appG.Response(http.StatusOK, recordings)
 }
 
+// SearchRecordings godoc
+// @Summary     Search the recordings by their metadata
+// @Description Returns the recordings whose title, description, category, uploader, channel name or any former title contains the query, the latest first.
+// @Tags        recordings
+// @Accept      json
+// @Produce     json
+// @Param       q query string true "Search text"
+// @Param       limit query int false "Max. number of results, at most 200"
+// @Success     200 {object} []database.Recording
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /recordings/search [get]
+func SearchRecordings(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		appG.Error(http.StatusBadRequest, errors.New("empty search query"))
+		return
+	}
+
+	recordings, err := services.SearchRecordings(query, limit)
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, recordings)
+}
+
+// GetTitleChanges godoc
+// @Summary     Return the title changes of a recording
+// @Description Returns the titles and categories of the broadcast during the capture, in the order they changed. The first entry is the metadata at the start of the capture.
+// @Tags        recordings
+// @Accept      json
+// @Produce     json
+// @Param       id path uint true "Recording item id"
+// @Success     200 {object} []database.RecordingTitleChange
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /recordings/{id}/titles [get]
+func GetTitleChanges(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	changes, err := services.GetTitleChanges(database.RecordingID(id))
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, changes)
+}
+
+// GetRetentionPreview godoc
+// @Summary     Returns the recordings which the retention policies would delete
+// @Description Dry-run of the retention policies, nothing is deleted.
+// @Tags        recordings
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} []database.Recording
+// @Failure     500 {} string "Error message"
+// @Router      /recordings/retention [get]
+func GetRetentionPreview(c *gin.Context) {
+	appG := app.Gin{C: c}
+	recordings, err := services.PlanRetention()
+
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, recordings)
+}
+
+// GetTimeshift godoc
+// @Summary     Return the HLS playlist of a recording which is still being captured
+// @Description Serves the event playlist (index.m3u8), which grows from the start of the recording up to the live edge, and its segments. If the authorization is passed as query parameter, it is appended to the segment URIs of the playlist.
+// @Tags        recordings
+// @Param       id path uint true "Recording item id"
+// @Param       file path string true "index.m3u8 or a segment"
+// @Produce     application/vnd.apple.mpegurl
+// @Success     200
+// @Failure     400 {} string "Error message"
+// @Failure     404 {} string "Error message"
+// @Router      /recordings/{id}/timeshift/{file} [get]
+func GetTimeshift(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	path, err := services.TimeshiftFile(database.RecordingID(id), c.Param("file"))
+	serveHLS(c, path, err, readPlaylist)
+}
+
 // GeneratePreviews godoc
 // @Summary     Generate preview for a certain video in a channel
 // @Description Generate preview for a certain video in a channel.
@@ -0,0 +1,271 @@
+package v1
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/srad/mediasink/app"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/models/requests"
+	"github.com/srad/mediasink/services"
+)
+
+// GetSchedules godoc
+// @Summary     Return the recording schedules of a channel
+// @Description Return the recurring recording windows of a channel. Channels without enabled schedules are recorded around the clock.
+// @Tags        schedules
+// @Param       id path uint true "Channel id"
+// @Produce     json
+// @Success     200 {object} []database.Schedule
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/schedules [get]
+func GetSchedules(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	schedules, err := database.ChannelID(id).FindSchedules()
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, schedules)
+}
+
+// CreateSchedule godoc
+// @Summary     Add a recording schedule to a channel
+// @Description Add a recurring recording window, i.e. weekdays 18:00-23:00. Weekdays are 0 (Sunday) to 6, an empty list means every day.
+// @Tags        schedules
+// @Param       id path uint true "Channel id"
+// @Param       ScheduleRequest body requests.ScheduleRequest true "Schedule data"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} database.Schedule
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/schedules [post]
+func CreateSchedule(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	data := &requests.ScheduleRequest{}
+	if err := c.BindJSON(&data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	schedule := scheduleFromRequest(database.ChannelID(id), data)
+	if err := services.ValidateSchedule(schedule); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := schedule.Create(); err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, schedule)
+}
+
+// UpdateSchedule godoc
+// @Summary     Update a recording schedule
+// @Description Update a recording schedule
+// @Tags        schedules
+// @Param       id path uint true "Schedule id"
+// @Param       ScheduleRequest body requests.ScheduleRequest true "Schedule data"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} database.Schedule
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /schedules/{id} [patch]
+func UpdateSchedule(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	data := &requests.ScheduleRequest{}
+	if err := c.BindJSON(&data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	existing, err := database.FindScheduleByID(database.ScheduleID(id))
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	schedule := scheduleFromRequest(existing.ChannelID, data)
+	schedule.ScheduleID = existing.ScheduleID
+	schedule.CreatedAt = existing.CreatedAt
+
+	if err := services.ValidateSchedule(schedule); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := schedule.Update(); err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, schedule)
+}
+
+// DeleteSchedule godoc
+// @Summary     Delete a recording schedule
+// @Description Delete a recording schedule
+// @Tags        schedules
+// @Param       id path uint true "Schedule id"
+// @Success     200
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /schedules/{id} [delete]
+func DeleteSchedule(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := database.DeleteSchedule(database.ScheduleID(id)); err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, nil)
+}
+
+// GetRecordingTimers godoc
+// @Summary     Return the one-off recording timers of a channel
+// @Description Return the one-off recording timers of a channel
+// @Tags        schedules
+// @Param       id path uint true "Channel id"
+// @Produce     json
+// @Success     200 {object} []database.RecordingTimer
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/timers [get]
+func GetRecordingTimers(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	timers, err := database.ChannelID(id).FindRecordingTimers()
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, timers)
+}
+
+// CreateRecordingTimer godoc
+// @Summary     Add a one-off recording timer to a channel
+// @Description Record the channel, or the given URL into the channel, for a duration in minutes, regardless of its schedules.
+// @Tags        schedules
+// @Param       id path uint true "Channel id"
+// @Param       RecordingTimerRequest body requests.RecordingTimerRequest true "Timer data"
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} database.RecordingTimer
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /channels/{id}/timers [post]
+func CreateRecordingTimer(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	data := &requests.RecordingTimerRequest{}
+	if err := c.BindJSON(&data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	if data.Duration == 0 {
+		appG.Error(http.StatusBadRequest, errors.New("the duration must be at least one minute"))
+		return
+	}
+
+	timer := &database.RecordingTimer{
+		ChannelID: database.ChannelID(id),
+		URL:       data.URL,
+		StartAt:   data.StartAt,
+		EndAt:     data.StartAt.Add(time.Duration(data.Duration) * time.Minute),
+	}
+
+	if err := timer.Create(); err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, timer)
+}
+
+// DeleteRecordingTimer godoc
+// @Summary     Delete a recording timer
+// @Description Delete a recording timer
+// @Tags        schedules
+// @Param       id path uint true "Timer id"
+// @Success     200
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /timers/{id} [delete]
+func DeleteRecordingTimer(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	if err := database.DeleteRecordingTimer(database.TimerID(id)); err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, nil)
+}
+
+func scheduleFromRequest(channelID database.ChannelID, data *requests.ScheduleRequest) *database.Schedule {
+	return &database.Schedule{
+		ChannelID: channelID,
+		Weekdays:  data.Weekdays,
+		StartTime: data.StartTime,
+		EndTime:   data.EndTime,
+		Timezone:  data.Timezone,
+		Enabled:   data.Enabled,
+	}
+}
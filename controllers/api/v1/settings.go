@@ -0,0 +1,67 @@
+package v1
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/srad/mediasink/app"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/models/requests"
+)
+
+// GetSettings godoc
+// @Summary     Returns all settings
+// @Description Returns all settings.
+// @Tags        settings
+// @Accept      json
+// @Produce     json
+// @Success     200 {object} []database.Setting
+// @Failure     500 {} string "Error message"
+// @Router      /settings [get]
+func GetSettings(c *gin.Context) {
+	appG := app.Gin{C: c}
+	settings, err := database.SettingsList()
+
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, settings)
+}
+
+// UpdateSettings godoc
+// @Summary     Update settings
+// @Description Update the values of existing settings.
+// @Tags        settings
+// @Accept      json
+// @Produce     json
+// @Param       SettingRequest body []requests.SettingRequest true "Settings"
+// @Success     200 {object} []database.Setting
+// @Failure     400 {} string "Error message"
+// @Failure     500 {} string "Error message"
+// @Router      /settings [patch]
+func UpdateSettings(c *gin.Context) {
+	appG := app.Gin{C: c}
+
+	var data []requests.SettingRequest
+	if err := c.BindJSON(&data); err != nil {
+		appG.Error(http.StatusBadRequest, err)
+		return
+	}
+
+	for _, setting := range data {
+		if err := database.UpdateValue(setting.SettingKey, setting.SettingValue); err != nil {
+			appG.Error(http.StatusBadRequest, err)
+			return
+		}
+	}
+
+	settings, err := database.SettingsList()
+	if err != nil {
+		appG.Error(http.StatusInternalServerError, err)
+		return
+	}
+
+	appG.Response(http.StatusOK, settings)
+}
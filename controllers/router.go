@@ -86,9 +86,26 @@ func Setup(version, commit string) http.Handler {
 		apiV1.PATCH("/channels/:id/unfav", middlewares.CheckAuthorizationHeader, v1.UnFavChannel)
 
 		apiV1.POST("/channels/:id/upload", middlewares.CheckAuthorizationHeader, v1.UploadChannel)
+		apiV1.POST("/channels/:id/fetch", middlewares.CheckAuthorizationHeader, v1.FetchChannel)
 
 		apiV1.PATCH("/channels/:id/tags", middlewares.CheckAuthorizationHeader, v1.TagChannel)
 
+		apiV1.GET("/channels/:id/sessions", middlewares.CheckAuthorizationHeader, v1.GetChannelHistory)
+		apiV1.GET("/channels/:id/items", middlewares.CheckAuthorizationHeader, v1.GetChannelItems)
+		apiV1.GET("/channels/:id/index", middlewares.CheckAuthorizationHeader, v1.GetCameraIndex)
+		apiV1.GET("/channels/:id/live/:file", middlewares.CheckAuthorizationHeader, v1.GetLiveStream)
+		apiV1.POST("/channels/:id/clip", middlewares.CheckAuthorizationHeader, v1.ClipChannel)
+
+		// Schedules
+		apiV1.GET("/channels/:id/schedules", middlewares.CheckAuthorizationHeader, v1.GetSchedules)
+		apiV1.POST("/channels/:id/schedules", middlewares.CheckAuthorizationHeader, v1.CreateSchedule)
+		apiV1.PATCH("/schedules/:id", middlewares.CheckAuthorizationHeader, v1.UpdateSchedule)
+		apiV1.DELETE("/schedules/:id", middlewares.CheckAuthorizationHeader, v1.DeleteSchedule)
+
+		apiV1.GET("/channels/:id/timers", middlewares.CheckAuthorizationHeader, v1.GetRecordingTimers)
+		apiV1.POST("/channels/:id/timers", middlewares.CheckAuthorizationHeader, v1.CreateRecordingTimer)
+		apiV1.DELETE("/timers/:id", middlewares.CheckAuthorizationHeader, v1.DeleteRecordingTimer)
+
 		// Jobs
 		apiV1.POST("/jobs/:id", middlewares.CheckAuthorizationHeader, v1.AddPreviewJobs)
 		apiV1.POST("/jobs/stop/:pid", middlewares.CheckAuthorizationHeader, v1.StopJob)
@@ -113,8 +130,12 @@ func Setup(version, commit string) http.Handler {
 		apiV1.GET("/recordings/filter/:column/:order/:limit", middlewares.CheckAuthorizationHeader, v1.FilterRecordings)
 		apiV1.GET("/recordings/random/:limit", middlewares.CheckAuthorizationHeader, v1.GetRandomRecordings)
 		apiV1.GET("/recordings/bookmarks", middlewares.CheckAuthorizationHeader, v1.GetBookmarks)
+		apiV1.GET("/recordings/search", middlewares.CheckAuthorizationHeader, v1.SearchRecordings)
+		apiV1.GET("/recordings/retention", middlewares.CheckAuthorizationHeader, v1.GetRetentionPreview)
 		apiV1.GET("/recordings/:id", middlewares.CheckAuthorizationHeader, v1.GetRecording)
 		apiV1.GET("/recordings/:id/download", middlewares.CheckAuthorizationHeader, v1.DownloadRecording)
+		apiV1.GET("/recordings/:id/titles", middlewares.CheckAuthorizationHeader, v1.GetTitleChanges)
+		apiV1.GET("/recordings/:id/timeshift/:file", middlewares.CheckAuthorizationHeader, v1.GetTimeshift)
 
 		apiV1.PATCH("/recordings/:id/fav", middlewares.CheckAuthorizationHeader, v1.FavRecording)
 		apiV1.PATCH("/recordings/:id/unfav", middlewares.CheckAuthorizationHeader, v1.UnfavRecording)
@@ -130,6 +151,10 @@ func Setup(version, commit string) http.Handler {
 
 		apiV1.GET("/processes", middlewares.CheckAuthorizationHeader, v1.GetProcesses)
 
+		// settings
+		apiV1.GET("/settings", middlewares.CheckAuthorizationHeader, v1.GetSettings)
+		apiV1.PATCH("/settings", middlewares.CheckAuthorizationHeader, v1.UpdateSettings)
+
 		go network.WsListen()
 		apiV1.GET("/ws", middlewares.CheckAuthorizationHeader, network.WsHandler)
 	}
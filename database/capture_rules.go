@@ -0,0 +1,79 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+// CaptureRules Decide which broadcasts of a channel are captured, empty rules capture everything.
+// The patterns are regular expressions, which match anywhere in the title or category. Stored as JSON object.
+type CaptureRules struct {
+	IncludeTitle    string `json:"includeTitle" extensions:"!x-nullable"`
+	ExcludeTitle    string `json:"excludeTitle" extensions:"!x-nullable"`
+	IncludeCategory string `json:"includeCategory" extensions:"!x-nullable"`
+	ExcludeCategory string `json:"excludeCategory" extensions:"!x-nullable"`
+	MinResolution   uint   `json:"minResolution" extensions:"!x-nullable"` // Height in pixels, 0 disables the rule
+	// StopOnExclude Stops a running capture once its title or category changes to one which the rules reject.
+	StopOnExclude bool `json:"stopOnExclude" extensions:"!x-nullable"`
+}
+
+func (rules *CaptureRules) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return errors.New("src value cannot cast to string")
+	}
+
+	parsed := CaptureRules{}
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &parsed); err != nil {
+			return fmt.Errorf("invalid capture rules '%s': %w", value, err)
+		}
+	}
+	*rules = parsed
+
+	return nil
+}
+
+func (rules CaptureRules) Value() (driver.Value, error) {
+	if rules.IsEmpty() {
+		return "", nil
+	}
+	if err := rules.IsValid(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(rules)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+func (rules CaptureRules) IsEmpty() bool {
+	return rules == CaptureRules{}
+}
+
+// IsValid All patterns must be valid regular expressions.
+func (rules CaptureRules) IsValid() error {
+	patterns := map[string]string{
+		"include title":    rules.IncludeTitle,
+		"exclude title":    rules.ExcludeTitle,
+		"include category": rules.IncludeCategory,
+		"exclude category": rules.ExcludeCategory,
+	}
+	for name, pattern := range patterns {
+		if _, err := regexp.Compile(pattern); err != nil {
+			return fmt.Errorf("invalid %s pattern '%s': %w", name, pattern, err)
+		}
+	}
+	return nil
+}
@@ -1,16 +1,15 @@
 package database
 
 import (
-	"context"
 	"errors"
 	"fmt"
-	"os/exec"
 	"path"
 	"strings"
 	"time"
 
 	"github.com/astaxie/beego/utils"
 	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/helpers"
 	"gorm.io/gorm"
 )
 
@@ -28,6 +27,58 @@ type Channel struct {
 	Deleted     bool        `json:"deleted" gorm:"not null,default:false" extensions:"!x-nullable"`
 	CreatedAt   time.Time   `json:"createdAt" gorm:"not null;default:current_timestamp" extensions:"!x-nullable"`
 
+	// Segment policy: a running capture rolls over into a new file once a limit is reached, 0 disables the limit.
+	SegmentDuration uint `json:"segmentDuration" gorm:"not null;default:0" extensions:"!x-nullable"` // Minutes
+	SegmentSize     uint `json:"segmentSize" gorm:"not null;default:0" extensions:"!x-nullable"`     // Megabytes
+
+	// Parts captured within the grace period after a stream dropped belong to the same session, 0 disables reconnects.
+	ReconnectGrace uint `json:"reconnectGrace" gorm:"not null;default:0" extensions:"!x-nullable"` // Seconds
+	AutoMerge      bool `json:"autoMerge" gorm:"not null;default:false" extensions:"!x-nullable"`
+
+	// How the stream URL is resolved: yt-dlp (default), direct or script. The script is a filename in the resolvers data folder.
+	Resolver       string `json:"resolver" gorm:"not null;default:''" extensions:"!x-nullable"`
+	FormatSelector string `json:"formatSelector" gorm:"not null;default:''" extensions:"!x-nullable"`
+	ResolverScript string `json:"resolverScript" gorm:"not null;default:''" extensions:"!x-nullable"`
+
+	// Applied to the stream resolution, the capture and the live snapshot. The cookies file is a filename in the cookies data folder.
+	MaxResolution uint    `json:"maxResolution" gorm:"not null;default:0" extensions:"!x-nullable"` // Height in pixels, 0 is unlimited
+	Headers       Headers `json:"headers" gorm:"type:text;not null;default:''" extensions:"!x-nullable"`
+	UserAgent     string  `json:"userAgent" gorm:"not null;default:''" extensions:"!x-nullable"`
+	CookiesFile   string  `json:"cookiesFile" gorm:"not null;default:''" extensions:"!x-nullable"`
+	Proxy         string  `json:"proxy" gorm:"not null;default:''" extensions:"!x-nullable"` // http://, https:// or socks5://
+
+	// Audio-only channels only capture the first audio stream, stored as aac (m4a), opus or mp3.
+	AudioOnly  bool   `json:"audioOnly" gorm:"not null;default:false" extensions:"!x-nullable"`
+	AudioCodec string `json:"audioCodec" gorm:"not null;default:''" extensions:"!x-nullable"`
+
+	// Radio channels read the ICY metadata into a cue sheet, optionally every track becomes its own recording.
+	Type        ChannelType `json:"type" gorm:"not null;default:''" extensions:"!x-nullable"`
+	SplitTracks bool        `json:"splitTracks" gorm:"not null;default:false" extensions:"!x-nullable"`
+
+	// Ingest channels listen on their port for a publisher, which must present the stream key.
+	IngestProtocol IngestProtocol `json:"ingestProtocol" gorm:"not null;default:''" extensions:"!x-nullable"`
+	IngestPort     uint           `json:"ingestPort" gorm:"not null;default:0" extensions:"!x-nullable"`
+	StreamKey      string         `json:"streamKey" gorm:"not null;default:''" extensions:"!x-nullable"`
+
+	// Feed and archive channels only download this many of the latest items when they are subscribed, the older ones are marked as known.
+	// 0 downloads all items.
+	Backfill uint `json:"backfill" gorm:"not null;default:0" extensions:"!x-nullable"`
+
+	// Camera channels delete their oldest segments once the ring quota is exceeded, 0 disables the ring buffer.
+	RingQuota uint `json:"ringQuota" gorm:"not null;default:0" extensions:"!x-nullable"` // Gigabytes
+
+	// Broadcasts which don't match the capture rules are skipped, see CaptureRules.
+	CaptureRules CaptureRules `json:"captureRules" gorm:"type:text;not null;default:''" extensions:"!x-nullable"`
+
+	// Retention policy: older recordings beyond these limits are deleted automatically, 0 disables a rule.
+	RetentionCount uint `json:"retentionCount" gorm:"not null;default:0" extensions:"!x-nullable"`
+	RetentionSize  uint `json:"retentionSize" gorm:"not null;default:0" extensions:"!x-nullable"` // Gigabytes
+	RetentionDays  uint `json:"retentionDays" gorm:"not null;default:0" extensions:"!x-nullable"`
+
+	// A capture which writes no data for this long is restarted, 0 applies the default timeout.
+	StallTimeout         uint `json:"stallTimeout" gorm:"not null;default:0" extensions:"!x-nullable"` // Seconds
+	DisableStallWatchdog bool `json:"disableStallWatchdog" gorm:"not null;default:false" extensions:"!x-nullable"`
+
 	// Only for query result.
 	RecordingsCount uint `json:"recordingsCount" gorm:"<-:false;-:migration" extensions:"!x-nullable"`
 	RecordingsSize  uint `json:"recordingsSize" gorm:"<-:false;-:migration" extensions:"!x-nullable"`
@@ -103,6 +154,16 @@ func CreateChannelDetail(channel Channel) (*Channel, error) {
 	return &channel, nil
 }
 
+// CapturesAudioOnly Radio channels are always audio-only.
+func (channel *Channel) CapturesAudioOnly() bool {
+	return channel.AudioOnly || channel.Type == ChannelTypeRadio
+}
+
+// AudioFormat Codec and container of the recordings of an audio-only channel.
+func (channel *Channel) AudioFormat() (helpers.AudioFormat, error) {
+	return helpers.GetAudioFormat(channel.AudioCodec)
+}
+
 func (channel *Channel) ExistsJSON() bool {
 	return utils.FileExists(channel.jsonPath())
 }
@@ -120,7 +181,8 @@ func (channel *Channel) Update() error {
 	url := strings.TrimSpace(channel.URL)
 	displayName := strings.TrimSpace(channel.DisplayName)
 
-	if len(url) == 0 || len(displayName) == 0 {
+	// Ingest channels have no URL, the stream is pushed to them.
+	if (len(url) == 0 && channel.Type != ChannelTypeIngest) || len(displayName) == 0 {
 		return fmt.Errorf("invalid parameters: %v", channel)
 	}
 
@@ -129,67 +191,11 @@ func (channel *Channel) Update() error {
 	return err
 }
 
-func (channel *Channel) QueryStreamURL() (string, error) {
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second) // 30-second timeout
-	defer cancel()
-
-	cmd := exec.CommandContext(ctx, "yt-dlp",
-		"--force-ipv4",
-		// "--ignore-errors", // Removed for better error handling
-		"--no-warnings",
-		"--youtube-skip-dash-manifest",
-		"-f", "best", // Or "best/bestvideo" if you have a strong reason for that specific fallback
-		"--get-url",
-		channel.URL,
-	)
-
-	outputBytes, err := cmd.CombinedOutput()
-	output := strings.TrimSpace(string(outputBytes))
-
-	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
-		return "", fmt.Errorf("yt-dlp command timed out for URL %s", channel.URL)
-	}
-
-	if err != nil {
-		// err from exec.CommandContext will be non-nil if youtube-dl exits with a non-zero status
-		// output will contain stderr from youtube-dl, which is useful context
-		return "", fmt.Errorf("yt-dlp failed for URL %s: %v\nOutput: %s", channel.URL, err, output)
-	}
-
-	// Basic validation: Does the output look like a URL?
-	// This is especially important if you were to re-add --ignore-errors.
-	// Even without it, youtube-dl might succeed (exit 0) but return multiple lines or an unexpected string.
-	// A more robust check might involve parsing the URL or checking for multiple lines.
-	if output == "" || (!strings.HasPrefix(output, "http://") && !strings.HasPrefix(output, "https://") && !strings.HasPrefix(output, "rtmp://")) {
-		// Consider if output might contain multiple URLs (one per line)
-		// For now, assume a single URL or an error string if it doesn't look like a URL
-		lines := strings.Split(output, "\n")
-		if len(lines) > 0 && (strings.HasPrefix(lines[0], "http://") || strings.HasPrefix(lines[0], "https://") || strings.HasPrefix(lines[0], "rtmp://")) {
-			// If the first line looks like a URL, use it (e.g. some extractors print metadata then the URL)
-			return lines[0], nil
-		}
-		return "", fmt.Errorf("yt-dlp returned empty or invalid output for URL %s: %s", channel.URL, output)
-	}
-
-	// If output contains multiple URLs (e.g. from a playlist if -g is used without --no-playlist),
-	// this will return all of them, separated by newlines.
-	// Your application needs to handle this (e.g., pick the first one).
-	// For a single video, it should be one URL.
-	// If you expect only one URL, you might want to split by newline and take lines[0].
-	lines := strings.Split(output, "\n")
-	if len(lines) > 0 {
-		return lines[0], nil // Return the first URL if multiple are given
-	}
-
-	// This part should ideally not be reached if the previous checks are robust.
-	return "", fmt.Errorf("yt-dlp returned unexpected data for URL %s: %s", channel.URL, output)
-}
-
 func ChannelList() ([]*Channel, error) {
 	var channels []*Channel
 
 	err := DB.Model(&Channel{}).
-		Select("channels.*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id) recordings_count", "(SELECT SUM(size) FROM recordings WHERE recordings.channel_name = channels.channel_name) recordings_size").
+		Select("channels.*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id AND recordings.status = 'ready') recordings_count", "(SELECT SUM(size) FROM recordings WHERE recordings.channel_name = channels.channel_name) recordings_size").
 		Find(&channels).Error
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -204,7 +210,7 @@ func ChannelListNotDeleted() ([]*Channel, error) {
 
 	err := DB.Model(&Channel{}).
 		Where("channels.deleted = ?", false).
-		Select("channels.*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id) recordings_count", "(SELECT SUM(size) FROM recordings WHERE recordings.channel_id = channels.channel_id) recordings_size").
+		Select("channels.*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id AND recordings.status = 'ready') recordings_count", "(SELECT SUM(size) FROM recordings WHERE recordings.channel_id = channels.channel_id) recordings_size").
 		Find(&result).Error
 
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
@@ -221,7 +227,7 @@ func EnabledChannelList() ([]*Channel, error) {
 	err := DB.Model(&Channel{}).
 		Where("deleted = ?", false).
 		Where("is_paused = ?", false).
-		Select("channels.*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id) recordings_count").
+		Select("channels.*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id AND recordings.status = 'ready') recordings_count").
 		Order("fav desc").
 		Find(&channels).Error
 
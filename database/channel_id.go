@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 	"gorm.io/gorm"
@@ -37,9 +38,9 @@ func GetChannelByIDWithRecordings(id ChannelID) (*Channel, error) {
 	var channel *Channel
 
 	err := DB.Model(&Channel{}).
-		Preload("Recordings").
+		Preload("Recordings", "status = ?", RecordingStatusReady).
 		Where("channels.channel_id = ?", id).
-		Select("*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id) recordings_count", "(SELECT SUM(size) FROM recordings WHERE recordings.channel_name = channels.channel_name) recordings_size").
+		Select("*", "(SELECT COUNT(*) FROM recordings WHERE recordings.channel_id = channels.channel_id AND recordings.status = 'ready') recordings_count", "(SELECT SUM(size) FROM recordings WHERE recordings.channel_name = channels.channel_name) recordings_size").
 		First(&channel).Error
 
 	if err != nil {
@@ -157,6 +158,44 @@ func NewRecording(channelID ChannelID, videoType string) (*Recording, string, er
 	}
 
 	filename, timestamp := channel.ChannelName.MakeRecordingFilename()
+	recording, filePath := newRecording(channel, filename, timestamp, videoType)
+
+	return recording, filePath, nil
+}
+
+// NewCaptureRecording Like NewRecording, but the file is a capture which needs to be finalized later.
+func NewCaptureRecording(channelID ChannelID, videoType string) (*Recording, string, error) {
+	channel, err := GetChannelByID(channelID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	filename, timestamp := channel.ChannelName.MakeCaptureFilename()
+	recording, filePath := newRecording(channel, filename, timestamp, videoType)
+
+	return recording, filePath, nil
+}
+
+// CreateDownloadRecording Persists the recording of a file which a job downloads later, see EnqueueDownloadJob.
+func CreateDownloadRecording(channelID ChannelID, extension, title string) (*Recording, error) {
+	channel, err := GetChannelByID(channelID)
+	if err != nil {
+		return nil, err
+	}
+
+	filename, timestamp := channel.ChannelName.MakeRecordingFilename()
+	recording, _ := newRecording(channel, filename.WithExtension(extension), timestamp, "recording")
+	recording.Title = title
+	recording.Status = RecordingStatusDownloading
+
+	if err := DB.Create(recording).Error; err != nil {
+		return nil, fmt.Errorf("error creating recording for download: %w", err)
+	}
+
+	return recording, nil
+}
+
+func newRecording(channel *Channel, filename RecordingFileName, timestamp time.Time, videoType string) (*Recording, string) {
 	relativePath := filepath.Join(channel.ChannelName.String(), filename.String())
 	filePath := channel.ChannelName.AbsoluteChannelFilePath(filename)
 
@@ -178,6 +217,5 @@ func NewRecording(channelID ChannelID, videoType string) (*Recording, string, er
 			PreviewVideo:  nil,
 			PreviewCover:  nil,
 		},
-		filePath,
-		nil
+		filePath
 }
@@ -0,0 +1,140 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ChannelItem An episode of a feed channel or a video of an archive channel. The item is kept after its
+// recording has been deleted, so that the episode is not downloaded again.
+type ChannelItem struct {
+	ChannelItemID uint         `json:"channelItemId" gorm:"autoIncrement;primaryKey;column:channel_item_id" extensions:"!x-nullable"`
+	Channel       Channel      `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:channel_id;references:channel_id"`
+	ChannelID     ChannelID    `json:"channelId" gorm:"not null;default:null;uniqueIndex:idx_channel_item_guid" extensions:"!x-nullable"`
+	GUID          string       `json:"guid" gorm:"column:guid;not null;uniqueIndex:idx_channel_item_guid" extensions:"!x-nullable"` // Archives: "<extractor> <video id>"
+	Title         string       `json:"title" gorm:"not null;default:''" extensions:"!x-nullable"`
+	Description   string       `json:"description" gorm:"not null;default:''" extensions:"!x-nullable"`
+	PublishedAt   *time.Time   `json:"publishedAt" gorm:"default:null"`
+	URL           string       `json:"url" gorm:"not null" extensions:"!x-nullable"` // Enclosure or video page
+	MediaType     string       `json:"mediaType" gorm:"not null;default:''" extensions:"!x-nullable"`
+	RecordingID   *RecordingID `json:"recordingId" gorm:"default:null;index"`
+	CreatedAt     time.Time    `json:"createdAt" gorm:"not null" extensions:"!x-nullable"`
+}
+
+// AddChannelItem Persists the item unless the channel already has an item with its GUID.
+// Returns false for known items.
+func AddChannelItem(item *ChannelItem) (bool, error) {
+	item.CreatedAt = time.Now()
+
+	result := DB.Clauses(clause.OnConflict{DoNothing: true}).Create(item)
+	if result.Error != nil {
+		return false, result.Error
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+// Delete Forgets the item, so that it is treated as new at the next poll.
+func (item *ChannelItem) Delete() error {
+	if err := DB.Delete(&ChannelItem{}, "channel_item_id = ?", item.ChannelItemID).Error; err != nil {
+		return fmt.Errorf("error deleting item '%s': %w", item.GUID, err)
+	}
+
+	return nil
+}
+
+// FindChannelItems The items of the channel, the latest publication first.
+func (channelId ChannelID) FindChannelItems() ([]*ChannelItem, error) {
+	var items []*ChannelItem
+
+	err := DB.Model(ChannelItem{}).
+		Where("channel_id = ?", channelId).
+		Order("published_at desc, channel_item_id desc").
+		Find(&items).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// CountChannelItems The number of known items, 0 until the channel has been polled.
+func (channelId ChannelID) CountChannelItems() (int64, error) {
+	var count int64
+
+	err := DB.Model(ChannelItem{}).
+		Where("channel_id = ?", channelId).
+		Count(&count).Error
+
+	return count, err
+}
+
+// FindChannelItem The feed item the recording has been downloaded from, nil if it has none.
+func (recordingID RecordingID) FindChannelItem() (*ChannelItem, error) {
+	var item *ChannelItem
+
+	err := DB.Model(ChannelItem{}).
+		Where("recording_id = ?", recordingID).
+		First(&item).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return item, err
+}
+
+func (item *ChannelItem) UpdateRecording(recordingID RecordingID) error {
+	if err := DB.Model(&ChannelItem{}).
+		Where("channel_item_id = ?", item.ChannelItemID).
+		Update("recording_id", recordingID).Error; err != nil {
+		return err
+	}
+
+	item.RecordingID = &recordingID
+
+	return nil
+}
+
+// UpdateMetadata Replaces the listed metadata of an archive video by the metadata of its download.
+func (item *ChannelItem) UpdateMetadata(title, description string, publishedAt *time.Time) error {
+	if err := DB.Model(&ChannelItem{}).
+		Where("channel_item_id = ?", item.ChannelItemID).
+		Updates(map[string]interface{}{"title": title, "description": description, "published_at": publishedAt}).Error; err != nil {
+		return fmt.Errorf("error updating metadata of item '%s': %w", item.GUID, err)
+	}
+
+	item.Title = title
+	item.Description = description
+	item.PublishedAt = publishedAt
+
+	return nil
+}
+
+// CreateRecording Persists the recording of the item before its enclosure is downloaded, the file is named after the item.
+func (item *ChannelItem) CreateRecording(extension string) (*Recording, error) {
+	channel, err := GetChannelByID(item.ChannelID)
+	if err != nil {
+		return nil, err
+	}
+
+	filename := RecordingFileName(fmt.Sprintf("%s_item_%d%s", channel.ChannelName, item.ChannelItemID, extension))
+	recording, _ := newRecording(channel, filename, time.Now(), "recording")
+	recording.Title = item.Title
+	recording.Status = RecordingStatusDownloading
+
+	if err := DB.Create(recording).Error; err != nil {
+		return nil, fmt.Errorf("error creating recording of item '%s': %w", item.GUID, err)
+	}
+
+	if err := item.UpdateRecording(recording.RecordingID); err != nil {
+		return nil, err
+	}
+
+	return recording, nil
+}
@@ -19,6 +19,7 @@ import (
 var (
 	validChannelName, _ = regexp.Compile("(?i)^[a-z_0-9]+$")
 	SnapshotFilename    = "live.jpg"
+	LiveFolder          = "live"
 )
 
 type ChannelName string
@@ -227,6 +228,11 @@ func (channelName ChannelName) MakeRecordingFilename() (RecordingFileName, time.
 	return RecordingFileName(fmt.Sprintf("%s_%s.mp4", channelName.String(), stamp)), now
 }
 
+func (channelName ChannelName) MakeCaptureFilename() (RecordingFileName, time.Time) {
+	filename, now := channelName.MakeRecordingFilename()
+	return filename.WithExtension(CaptureExtension), now
+}
+
 func (channelName ChannelName) MakeMp3Filename() (RecordingFileName, time.Time) {
 	now := time.Now()
 	stamp := now.Format("2006_01_02_15_04_05")
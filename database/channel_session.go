@@ -0,0 +1,144 @@
+package database
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ChannelSessionID uint
+
+// ChannelSession A period in which the channel was online and captured, from the first part until the
+// reconnect grace period of the last part expired. The recordings share its session id.
+type ChannelSession struct {
+	ChannelSessionID ChannelSessionID `json:"channelSessionId" gorm:"autoIncrement;primaryKey;column:channel_session_id" extensions:"!x-nullable"`
+	Channel          Channel          `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:channel_id;references:channel_id"`
+	ChannelID        ChannelID        `json:"channelId" gorm:"not null;default:null;index" extensions:"!x-nullable"`
+	SessionID        SessionID        `json:"sessionId" gorm:"not null;uniqueIndex" extensions:"!x-nullable"`
+	StartedAt        time.Time        `json:"startedAt" gorm:"not null;index" extensions:"!x-nullable"`
+	EndedAt          *time.Time       `json:"endedAt" gorm:"default:null"` // nil while the channel is online
+
+	// Only for query result.
+	Recordings []*Recording `json:"recordings" gorm:"-"`
+}
+
+type ChannelSessionEventType string
+
+const (
+	ChannelSessionEventOnline    ChannelSessionEventType = "online"
+	ChannelSessionEventReconnect ChannelSessionEventType = "reconnect"
+	ChannelSessionEventOffline   ChannelSessionEventType = "offline"
+	ChannelSessionEventError     ChannelSessionEventType = "error"
+	ChannelSessionEventStalled   ChannelSessionEventType = "stalled"
+	ChannelSessionEventSkipped   ChannelSessionEventType = "skipped" // The broadcast did not match the capture rules
+	ChannelSessionEventStopped   ChannelSessionEventType = "stopped" // The capture was stopped by the capture rules
+)
+
+// ChannelSessionEvent A single transition of the channel, or an error while resolving its stream.
+type ChannelSessionEvent struct {
+	ChannelSessionEventID uint                    `json:"channelSessionEventId" gorm:"autoIncrement;primaryKey;column:channel_session_event_id" extensions:"!x-nullable"`
+	Channel               Channel                 `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:channel_id;references:channel_id"`
+	ChannelID             ChannelID               `json:"channelId" gorm:"not null;default:null;index" extensions:"!x-nullable"`
+	SessionID             *SessionID              `json:"sessionId" gorm:"default:null"`
+	Event                 ChannelSessionEventType `json:"event" gorm:"not null" extensions:"!x-nullable"`
+	Message               string                  `json:"message" gorm:"not null;default:''" extensions:"!x-nullable"`
+	CreatedAt             time.Time               `json:"createdAt" gorm:"not null;index" extensions:"!x-nullable"`
+}
+
+func OpenChannelSession(channelID ChannelID, sessionID SessionID, startedAt time.Time) (*ChannelSession, error) {
+	session := &ChannelSession{ChannelID: channelID, SessionID: sessionID, StartedAt: startedAt}
+	if err := DB.Create(session).Error; err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+func (sessionID SessionID) CloseChannelSession(endedAt time.Time) error {
+	return DB.Model(&ChannelSession{}).
+		Where("session_id = ?", sessionID).
+		Update("ended_at", endedAt).Error
+}
+
+// FindOpenChannelSessions Sessions which have not been closed, i.e. when the server stopped during a capture.
+func FindOpenChannelSessions() ([]*ChannelSession, error) {
+	var sessions []*ChannelSession
+
+	err := DB.Model(&ChannelSession{}).
+		Where("ended_at IS NULL").
+		Find(&sessions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return sessions, nil
+}
+
+// FindChannelSessions The sessions of the channel since the given time, newest first, including their recordings.
+func (channelId ChannelID) FindChannelSessions(since time.Time) ([]*ChannelSession, error) {
+	var sessions []*ChannelSession
+
+	err := DB.Model(&ChannelSession{}).
+		Where("channel_id = ? AND started_at >= ?", channelId, since).
+		Order("started_at desc").
+		Find(&sessions).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	if len(sessions) == 0 {
+		return sessions, nil
+	}
+
+	sessionIDs := make([]SessionID, len(sessions))
+	bySessionID := make(map[SessionID]*ChannelSession, len(sessions))
+	for i, session := range sessions {
+		sessionIDs[i] = session.SessionID
+		bySessionID[session.SessionID] = session
+		session.Recordings = []*Recording{}
+	}
+
+	var recordings []*Recording
+	if err := DB.Model(&Recording{}).
+		Where("session_id IN ? AND status <> ?", sessionIDs, RecordingStatusDiscarded).
+		Order("recordings.created_at asc").
+		Find(&recordings).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	for _, recording := range recordings {
+		if session, ok := bySessionID[*recording.SessionID]; ok {
+			session.Recordings = append(session.Recordings, recording)
+		}
+	}
+
+	return sessions, nil
+}
+
+func AddChannelSessionEvent(channelID ChannelID, sessionID *SessionID, event ChannelSessionEventType, message string) error {
+	return DB.Create(&ChannelSessionEvent{
+		ChannelID: channelID,
+		SessionID: sessionID,
+		Event:     event,
+		Message:   message,
+		CreatedAt: time.Now(),
+	}).Error
+}
+
+// FindChannelSessionEvents The events of the channel since the given time, newest first.
+func (channelId ChannelID) FindChannelSessionEvents(since time.Time) ([]*ChannelSessionEvent, error) {
+	var events []*ChannelSessionEvent
+
+	err := DB.Model(&ChannelSessionEvent{}).
+		Where("channel_id = ? AND created_at >= ?", channelId, since).
+		Order("created_at desc").
+		Find(&events).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return events, nil
+}
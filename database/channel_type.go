@@ -0,0 +1,33 @@
+package database
+
+import "fmt"
+
+// ChannelType Determines how a channel is captured, an empty type is a stream.
+type ChannelType string
+
+const (
+	// ChannelTypeStream The URL is resolved and captured by ffmpeg.
+	ChannelTypeStream ChannelType = "stream"
+	// ChannelTypeRadio An Icecast/Shoutcast stream, whose ICY metadata is read while capturing.
+	ChannelTypeRadio ChannelType = "radio"
+	// ChannelTypeIngest The stream is pushed by a publisher via RTMP or SRT, see IngestProtocol.
+	ChannelTypeIngest ChannelType = "ingest"
+	// ChannelTypeCamera An RTSP camera, which is recorded continuously in fixed-length segments.
+	ChannelTypeCamera ChannelType = "camera"
+	// ChannelTypeFeed An RSS/Atom feed, whose enclosures are downloaded as recordings.
+	ChannelTypeFeed ChannelType = "feed"
+	// ChannelTypeArchive A playlist or uploads page, whose videos are downloaded by yt-dlp as recordings.
+	ChannelTypeArchive ChannelType = "archive"
+)
+
+func (channelType ChannelType) String() string {
+	return string(channelType)
+}
+
+func (channelType ChannelType) IsValid() error {
+	switch channelType {
+	case "", ChannelTypeStream, ChannelTypeRadio, ChannelTypeIngest, ChannelTypeCamera, ChannelTypeFeed, ChannelTypeArchive:
+		return nil
+	}
+	return fmt.Errorf("unknown channel type '%s'", channelType)
+}
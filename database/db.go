@@ -71,6 +71,24 @@ func migrate() {
 	if err := DB.AutoMigrate(&Job{}); err != nil {
 		panic(fmt.Sprintf("[Migrate] Error Job: %s", err))
 	}
+	if err := DB.AutoMigrate(&Schedule{}); err != nil {
+		panic(fmt.Sprintf("[Migrate] Error Schedule: %s", err))
+	}
+	if err := DB.AutoMigrate(&RecordingTimer{}); err != nil {
+		panic(fmt.Sprintf("[Migrate] Error RecordingTimer: %s", err))
+	}
+	if err := DB.AutoMigrate(&ChannelSession{}); err != nil {
+		panic(fmt.Sprintf("[Migrate] Error ChannelSession: %s", err))
+	}
+	if err := DB.AutoMigrate(&ChannelSessionEvent{}); err != nil {
+		panic(fmt.Sprintf("[Migrate] Error ChannelSessionEvent: %s", err))
+	}
+	if err := DB.AutoMigrate(&ChannelItem{}); err != nil {
+		panic(fmt.Sprintf("[Migrate] Error ChannelItem: %s", err))
+	}
+	if err := DB.AutoMigrate(&RecordingTitleChange{}); err != nil {
+		panic(fmt.Sprintf("[Migrate] Error RecordingTitleChange: %s", err))
+	}
 	if err := DB.AutoMigrate(&Setting{}); err != nil {
 		panic(fmt.Sprintf("[Migrate] Error Setting: %s", err))
 	}
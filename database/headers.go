@@ -0,0 +1,63 @@
+package database
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Headers Custom HTTP headers of a channel. Stored as JSON object.
+type Headers map[string]string
+
+func (o *Headers) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return errors.New("src value cannot cast to string")
+	}
+
+	headers := Headers{}
+	if value != "" {
+		if err := json.Unmarshal([]byte(value), &headers); err != nil {
+			return fmt.Errorf("invalid headers '%s': %w", value, err)
+		}
+	}
+	*o = headers
+
+	return nil
+}
+
+func (o Headers) Value() (driver.Value, error) {
+	if len(o) == 0 {
+		return "", nil
+	}
+	if err := o.IsValid(); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(o)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// IsValid The headers are passed on to yt-dlp and ffmpeg, so they must not contain line breaks.
+func (o Headers) IsValid() error {
+	for key, value := range o {
+		if key == "" || strings.ContainsAny(key, ": \r\n") {
+			return fmt.Errorf("invalid header name '%s'", key)
+		}
+		if strings.ContainsAny(value, "\r\n") {
+			return fmt.Errorf("invalid value of header '%s'", key)
+		}
+	}
+	return nil
+}
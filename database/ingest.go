@@ -0,0 +1,58 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+)
+
+// IngestProtocol Protocol an ingest channel listens on, an empty protocol is RTMP.
+type IngestProtocol string
+
+const (
+	IngestProtocolRTMP IngestProtocol = "rtmp"
+	IngestProtocolSRT  IngestProtocol = "srt"
+)
+
+const (
+	// The stream key doubles as SRT passphrase, which must have 10 to 79 characters.
+	minStreamKeyLength = 10
+	maxStreamKeyLength = 79
+)
+
+func (protocol IngestProtocol) String() string {
+	if protocol == "" {
+		return string(IngestProtocolRTMP)
+	}
+	return string(protocol)
+}
+
+func (protocol IngestProtocol) IsValid() error {
+	switch protocol {
+	case "", IngestProtocolRTMP, IngestProtocolSRT:
+		return nil
+	}
+	return fmt.Errorf("unknown ingest protocol '%s'", protocol)
+}
+
+// NewStreamKey Generates a random stream key for an ingest channel.
+func NewStreamKey() string {
+	b := make([]byte, 16)
+	// crypto/rand.Read never returns an error on supported platforms.
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// IsValidStreamKey The key is part of the RTMP URL, so only letters, digits, dashes and underscores are allowed.
+func IsValidStreamKey(key string) error {
+	if len(key) < minStreamKeyLength || len(key) > maxStreamKeyLength {
+		return fmt.Errorf("stream key must have %d to %d characters", minStreamKeyLength, maxStreamKeyLength)
+	}
+	for _, c := range key {
+		if !(c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_') {
+			return errors.New("stream key must only contain letters, digits, dashes and underscores")
+		}
+	}
+	return nil
+}
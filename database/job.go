@@ -20,6 +20,10 @@ const (
 	TaskPreviewStrip   JobTask   = "preview-stripe"
 	TaskPreviewVideo   JobTask   = "preview-video"
 	TaskCut            JobTask   = "cut"
+	TaskFinalize       JobTask   = "finalize"
+	TaskMerge          JobTask   = "merge"
+	TaskClip           JobTask   = "clip"
+	TaskDownload       JobTask   = "download"
 	StatusJobCompleted JobStatus = "completed"
 	StatusJobOpen      JobStatus = "open"
 	StatusJobError     JobStatus = "error"
@@ -178,11 +182,31 @@ func DeleteJob(id uint) error {
 	return nil
 }
 
-// GetNextJob Any job is attached to a recording which it will process.
-// The caller must know which type the JSON serialized argument originally had.
-func GetNextJob() (*Job, error) {
+// GetNextJob Returns the oldest open job, optionally only of the given tasks.
+// Any job is attached to a recording which it will process, the caller must know which type the JSON serialized argument originally had.
+func GetNextJob(tasks ...JobTask) (*Job, error) {
+	var job *Job
+	query := DB.Where("status = ? AND active = ?", StatusJobOpen, false)
+	if len(tasks) > 0 {
+		query = query.Where("task IN ?", tasks)
+	}
+	err := query.
+		Preload("Channel").
+		Preload("Recording").
+		Order("jobs.created_at ASC").
+		First(&job).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return job, err
+}
+
+// GetNextJobExcept Returns the oldest open job which is none of the given tasks.
+func GetNextJobExcept(tasks ...JobTask) (*Job, error) {
 	var job *Job
-	err := DB.Where("status = ? AND active = ?", StatusJobOpen, false).
+	err := DB.Where("status = ? AND active = ? AND task NOT IN ?", StatusJobOpen, false, tasks).
 		Preload("Channel").
 		Preload("Recording").
 		Order("jobs.created_at ASC").
@@ -267,6 +291,42 @@ func (job *Job) Activate() error {
 	return DB.Model(&Job{}).Where("job_id = ?", job.JobID).Updates(map[string]interface{}{"started_at": time.Now(), "active": true}).Error
 }
 
+// DeactivateJobs Resets the active flag of all jobs, i.e. jobs which were interrupted by a server crash.
+func DeactivateJobs() error {
+	return DB.Model(&Job{}).Where("active = ?", true).Update("active", false).Error
+}
+
+// AttachRecording Moves the job to another recording, i.e. to its result, before the recording it processed is deleted.
+func (job *Job) AttachRecording(recording *Recording) error {
+	if job.JobID == 0 {
+		return errors.New("invalid job id")
+	}
+
+	absolutePath := recording.ChannelName.AbsoluteChannelFilePath(recording.Filename)
+	if err := DB.Model(&Job{}).Where("job_id = ?", job.JobID).Updates(map[string]any{
+		"recording_id": recording.RecordingID,
+		"filename":     recording.Filename,
+		"filepath":     absolutePath,
+	}).Error; err != nil {
+		return fmt.Errorf("error moving job %d to recording %d: %w", job.JobID, recording.RecordingID, err)
+	}
+
+	job.RecordingID = recording.RecordingID
+	job.Filename = recording.Filename
+	job.Filepath = absolutePath
+
+	return nil
+}
+
+// Postpone Moves the job to the end of the queue, so the jobs it waits for run first.
+func (job *Job) Postpone() error {
+	if job.JobID == 0 {
+		return errors.New("invalid job id")
+	}
+
+	return DB.Model(&Job{}).Where("job_id = ?", job.JobID).Update("created_at", time.Now()).Error
+}
+
 func (job *Job) Deactivate() error {
 	if job.JobID == 0 {
 		return errors.New("invalid job id")
@@ -307,6 +367,30 @@ func (recording *Recording) EnqueueConversionJob(mediaType string) (*Job, error)
 	return enqueueJob[string](recording, TaskConvert, &mediaType)
 }
 
+// EnqueueFinalizeJob Schedules the remux of a capture into its final mp4 file.
+func (recording *Recording) EnqueueFinalizeJob() (*Job, error) {
+	job, exists, err := JobExists(recording.RecordingID, TaskFinalize)
+	if err != nil {
+		return job, err
+	}
+	if exists {
+		return job, nil
+	}
+	return enqueueJob[*any](recording, TaskFinalize, nil)
+}
+
+// EnqueueMergeJob Schedules the concatenation of all parts of the session into a single recording.
+func (recording *Recording) EnqueueMergeJob(sessionID SessionID) (*Job, error) {
+	job, exists, err := JobExists(recording.RecordingID, TaskMerge)
+	if err != nil {
+		return job, err
+	}
+	if exists {
+		return job, nil
+	}
+	return enqueueJob[SessionID](recording, TaskMerge, &sessionID)
+}
+
 func (recording *Recording) EnqueuePreviewsJob() (*Job, *Job, error) {
 	job1, err1 := recording.EnqueuePreviewCoverJob()
 	job2, err2 := recording.EnqueuePreviewStripeJob()
@@ -352,6 +436,22 @@ func (recording *Recording) EnqueueCuttingJob(args *helpers.CutArgs) (*Job, erro
 	return enqueueJob(recording, TaskCut, args)
 }
 
+func (recording *Recording) EnqueueClipJob(args *helpers.ClipArgs) (*Job, error) {
+	return enqueueJob(recording, TaskClip, args)
+}
+
+// EnqueueDownloadJob Schedules the download of the URL into the file of the recording.
+func (recording *Recording) EnqueueDownloadJob(url string) (*Job, error) {
+	job, exists, err := JobExists(recording.RecordingID, TaskDownload)
+	if err != nil {
+		return job, err
+	}
+	if exists {
+		return job, nil
+	}
+	return enqueueJob[string](recording, TaskDownload, &url)
+}
+
 func enqueueJob[T any](recording *Recording, task JobTask, args *T) (*Job, error) {
 	if job, err := CreateJob(recording, task, args); err != nil {
 		return nil, err
@@ -4,6 +4,7 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"time"
 
 	"github.com/astaxie/beego/utils"
@@ -35,6 +36,24 @@ type Recording struct {
 	CreatedAt   time.Time         `json:"createdAt" gorm:"not null;default:null;index" extensions:"!x-nullable"`
 	VideoType   string            `json:"videoType" gorm:"default:null;not null" extensions:"!x-nullable" validate:"required"`
 
+	// All segments of one capture share the same session id.
+	SessionID *SessionID `json:"sessionId" gorm:"default:null;index"`
+
+	// Title of the broadcast, i.e. the track of a radio stream.
+	Title string `json:"title" gorm:"not null;default:''" extensions:"!x-nullable"`
+
+	// Metadata of the broadcast when the capture started, as reported by the resolver, see RecordingTitleChange.
+	Description  string `json:"description" gorm:"not null;default:''" extensions:"!x-nullable"`
+	Category     string `json:"category" gorm:"not null;default:''" extensions:"!x-nullable"`
+	Uploader     string `json:"uploader" gorm:"not null;default:''" extensions:"!x-nullable"`
+	ThumbnailURL string `json:"thumbnailUrl" gorm:"not null;default:''" extensions:"!x-nullable"`
+	OriginalID   string `json:"originalId" gorm:"not null;default:''" extensions:"!x-nullable"`
+
+	Status         RecordingStatus `json:"status" gorm:"not null;default:'ready';index" extensions:"!x-nullable"`
+	StartedAt      *time.Time      `json:"startedAt" gorm:"default:null"`
+	BytesWritten   uint64          `json:"bytesWritten" gorm:"not null;default:0" extensions:"!x-nullable"`
+	LastProgressAt *time.Time      `json:"lastProgressAt" gorm:"default:null"`
+
 	Packets  uint64  `json:"packets" gorm:"default:0;not null" extensions:"!x-nullable"` // Total number of video packets/frames.
 	Duration float64 `json:"duration" gorm:"default:0;not null" extensions:"!x-nullable"`
 	Size     uint64  `json:"size" gorm:"default:0;not null" extensions:"!x-nullable"`
@@ -74,6 +93,7 @@ func SortBy(column, order string, limit int) ([]*Recording, error) {
 	var recordings []*Recording
 
 	err := DB.Model(Recording{}).
+		Where("status = ?", RecordingStatusReady).
 		Order(fmt.Sprintf("recordings.%s %s", column, order)).
 		Limit(limit).
 		Find(&recordings).Error
@@ -89,6 +109,7 @@ func FindRandom(limit int) ([]*Recording, error) {
 	var recordings []*Recording
 
 	err := DB.Model(Recording{}).
+		Where("status = ?", RecordingStatusReady).
 		Order("RANDOM()").
 		Limit(limit).
 		Find(&recordings).Error
@@ -117,7 +138,7 @@ func RecordingsList() ([]*Recording, error) {
 func BookmarkList() ([]*Recording, error) {
 	var recordings []*Recording
 	err := DB.Model(Recording{}).
-		Where("bookmark = ?", true).
+		Where("bookmark = ? AND status = ?", true, RecordingStatusReady).
 		Select("recordings.*").Order("recordings.channel_name asc").
 		Find(&recordings).Error
 
@@ -169,6 +190,15 @@ func DeletePreview(channelName ChannelName, filename RecordingFileName, previewT
 }
 
 func CreateRecording(channelId ChannelID, filename RecordingFileName, videoType string) (*Recording, error) {
+	return createRecording(channelId, filename, videoType, nil)
+}
+
+// CreateSessionRecording Same as CreateRecording, but links the recording to a capture session.
+func CreateSessionRecording(channelId ChannelID, filename RecordingFileName, videoType string, sessionID SessionID) (*Recording, error) {
+	return createRecording(channelId, filename, videoType, &sessionID)
+}
+
+func createRecording(channelId ChannelID, filename RecordingFileName, videoType string, sessionID *SessionID) (*Recording, error) {
 	channel, errChannel := GetChannelByID(channelId)
 	if errChannel != nil {
 		return nil, errChannel
@@ -188,6 +218,8 @@ func CreateRecording(channelId ChannelID, filename RecordingFileName, videoType
 		Bookmark:      false,
 		CreatedAt:     time.Now(),
 		VideoType:     videoType,
+		SessionID:     sessionID,
+		Status:        RecordingStatusReady,
 		Packets:       info.PacketCount,
 		Duration:      info.Duration,
 		Size:          info.Size,
@@ -268,6 +300,12 @@ func DeleteFile(channelName ChannelName, filename RecordingFileName) error {
 		return fmt.Errorf("error deleting recording: %s", err)
 	}
 
+	// Radio captures have a cue sheet next to the file.
+	cueSheet := channelName.AbsoluteChannelFilePath(filename.WithExtension(CueSheetExtension))
+	if err := os.Remove(cueSheet); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error deleting cue sheet: %s", err)
+	}
+
 	return nil
 }
 
@@ -295,6 +333,21 @@ func (channelId ChannelID) FindJobs() (*[]Job, error) {
 	return jobs, nil
 }
 
+// FindRecordingByFilename The recording of a file in the channel folder, nil if the file has none.
+func FindRecordingByFilename(channelName ChannelName, filename RecordingFileName) (*Recording, error) {
+	var recording *Recording
+
+	err := DB.Model(Recording{}).
+		Where("channel_name = ? AND filename = ?", channelName, filename).
+		First(&recording).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return recording, err
+}
+
 func AddIfNotExists(channelId ChannelID, channelName ChannelName, filename RecordingFileName) (*Recording, error) {
 	var recording *Recording
 
@@ -325,6 +378,30 @@ func (recording *Recording) UpdateInfo(info *helpers.FFProbeInfo) error {
 	return DB.Model(recording).Where("recording_id = ?", recording.RecordingID).Updates(&Recording{ChannelName: recording.ChannelName, Filename: recording.Filename, Duration: info.Duration, BitRate: info.BitRate, Size: info.Size, Width: info.Width, Height: info.Height, Packets: info.PacketCount}).Error
 }
 
+// Rename Points the recording to another file in the channel folder, i.e. after a capture has been finalized.
+func (recording *Recording) Rename(filename RecordingFileName) error {
+	pathRelative := recording.ChannelName.ChannelPath(filename)
+
+	if err := DB.Model(&Recording{}).
+		Where("recording_id = ?", recording.RecordingID).
+		Updates(map[string]interface{}{"filename": filename, "path_relative": pathRelative}).Error; err != nil {
+		return fmt.Errorf("error renaming recording '%s' to '%s': %w", recording.Filename, filename, err)
+	}
+
+	recording.Filename = filename
+	recording.PathRelative = pathRelative
+
+	return nil
+}
+
+func (recording *Recording) UpdateTitle(title string) error {
+	if err := DB.Model(&Recording{}).Where("recording_id = ?", recording.RecordingID).Update("title", title).Error; err != nil {
+		return fmt.Errorf("error updating title of recording '%s': %w", recording.Filename, err)
+	}
+	recording.Title = title
+	return nil
+}
+
 func (recording *Recording) AbsoluteChannelFilepath() string {
 	return recording.ChannelName.AbsoluteChannelFilePath(recording.Filename)
 }
@@ -333,6 +410,11 @@ func (recording *Recording) DataFolder() string {
 	return recording.ChannelName.AbsoluteChannelDataPath()
 }
 
+// LiveFolder Contains the HLS playlist of the recording while it is being captured.
+func (recording *Recording) LiveFolder() string {
+	return filepath.Join(recording.DataFolder(), LiveFolder, fmt.Sprintf("%d", recording.RecordingID))
+}
+
 func AddPreviewPaths(recordingID RecordingID) error {
 	if recordingID == 0 {
 		return errors.New("invalid job id")
@@ -1,7 +1,29 @@
 package database
 
+import (
+	"path/filepath"
+	"strings"
+)
+
+// CaptureExtension Captures are written as MPEG-TS which stays readable if the process or server dies.
+// They are remuxed to mp4 when the capture is finalized.
+const CaptureExtension = ".ts"
+
+// CueSheetExtension Track list of a radio capture, it shares the name of the recording.
+const CueSheetExtension = ".cue"
+
 type RecordingFileName string
 
 func (filename RecordingFileName) String() string {
 	return string(filename)
 }
+
+// IsCapture Returns true if the file is an unfinalized capture.
+func (filename RecordingFileName) IsCapture() bool {
+	return filepath.Ext(filename.String()) == CaptureExtension
+}
+
+func (filename RecordingFileName) WithExtension(extension string) RecordingFileName {
+	name := filename.String()
+	return RecordingFileName(strings.TrimSuffix(name, filepath.Ext(name)) + extension)
+}
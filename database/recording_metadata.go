@@ -0,0 +1,115 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// StreamMetadata Describes a broadcast, as reported by the resolver of the channel.
+type StreamMetadata struct {
+	Title        string
+	Description  string
+	Category     string
+	Uploader     string
+	ThumbnailURL string
+	OriginalID   string
+}
+
+// RecordingTitleChange The title and category of a broadcast from the time of the change, the first
+// entry of a recording is its metadata when the capture started.
+type RecordingTitleChange struct {
+	RecordingTitleChangeID uint        `json:"recordingTitleChangeId" gorm:"autoIncrement;primaryKey;column:recording_title_change_id" extensions:"!x-nullable"`
+	Recording              Recording   `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:recording_id;references:recording_id"`
+	RecordingID            RecordingID `json:"recordingId" gorm:"not null;default:null;index" extensions:"!x-nullable"`
+	Title                  string      `json:"title" gorm:"not null;default:''" extensions:"!x-nullable"`
+	Category               string      `json:"category" gorm:"not null;default:''" extensions:"!x-nullable"`
+	ChangedAt              time.Time   `json:"changedAt" gorm:"not null" extensions:"!x-nullable"`
+}
+
+// UpdateMetadata Replaces the metadata of the recording.
+func (recording *Recording) UpdateMetadata(metadata StreamMetadata) error {
+	if err := DB.Model(&Recording{}).
+		Where("recording_id = ?", recording.RecordingID).
+		Updates(map[string]interface{}{
+			"title":         metadata.Title,
+			"description":   metadata.Description,
+			"category":      metadata.Category,
+			"uploader":      metadata.Uploader,
+			"thumbnail_url": metadata.ThumbnailURL,
+			"original_id":   metadata.OriginalID,
+		}).Error; err != nil {
+		return fmt.Errorf("error updating metadata of recording '%s': %w", recording.Filename, err)
+	}
+
+	recording.Title = metadata.Title
+	recording.Description = metadata.Description
+	recording.Category = metadata.Category
+	recording.Uploader = metadata.Uploader
+	recording.ThumbnailURL = metadata.ThumbnailURL
+	recording.OriginalID = metadata.OriginalID
+
+	return nil
+}
+
+func AddRecordingTitleChange(recordingID RecordingID, title, category string, changedAt time.Time) error {
+	return DB.Create(&RecordingTitleChange{
+		RecordingID: recordingID,
+		Title:       title,
+		Category:    category,
+		ChangedAt:   changedAt,
+	}).Error
+}
+
+// FindTitleChanges The title changes of the recording in the order they happened.
+func (recordingID RecordingID) FindTitleChanges() ([]*RecordingTitleChange, error) {
+	var changes []*RecordingTitleChange
+
+	err := DB.Model(&RecordingTitleChange{}).
+		Where("recording_id = ?", recordingID).
+		Order("changed_at asc, recording_title_change_id asc").
+		Find(&changes).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+// likePattern Matches the query anywhere in a lowercased column, wildcards within the query are taken literally.
+func likePattern(query string) string {
+	escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(strings.ToLower(query))
+	return "%" + escaped + "%"
+}
+
+// SearchRecordings The ready recordings whose metadata or any former title contains the query, the latest first.
+func SearchRecordings(query string, limit int) ([]*Recording, error) {
+	var recordings []*Recording
+	pattern := likePattern(query)
+
+	titleChanges := DB.Model(&RecordingTitleChange{}).
+		Select("recording_id").
+		Where(`LOWER(title) LIKE ? ESCAPE '\' OR LOWER(category) LIKE ? ESCAPE '\'`, pattern, pattern)
+
+	err := DB.Model(&Recording{}).
+		Where("status = ?", RecordingStatusReady).
+		Where(DB.Where(`LOWER(recordings.title) LIKE ? ESCAPE '\'`, pattern).
+			Or(`LOWER(recordings.description) LIKE ? ESCAPE '\'`, pattern).
+			Or(`LOWER(recordings.category) LIKE ? ESCAPE '\'`, pattern).
+			Or(`LOWER(recordings.uploader) LIKE ? ESCAPE '\'`, pattern).
+			Or(`LOWER(recordings.channel_name) LIKE ? ESCAPE '\'`, pattern).
+			Or("recordings.recording_id IN (?)", titleChanges)).
+		Order("recordings.created_at desc").
+		Limit(limit).
+		Find(&recordings).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return recordings, nil
+}
@@ -0,0 +1,117 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// RecordingStatus Lifecycle of a recording: recording -> finalizing -> ready, failed or discarded.
+// Files imported or created by jobs are ready right away, downloads are ready once the download job finished.
+type RecordingStatus string
+
+const (
+	RecordingStatusRecording   RecordingStatus = "recording"
+	RecordingStatusFinalizing  RecordingStatus = "finalizing"
+	RecordingStatusReady       RecordingStatus = "ready"
+	RecordingStatusFailed      RecordingStatus = "failed"
+	RecordingStatusDiscarded   RecordingStatus = "discarded"
+	RecordingStatusDownloading RecordingStatus = "downloading"
+)
+
+func (status RecordingStatus) String() string {
+	return string(status)
+}
+
+// CreateCaptureRecording Persists a recording as soon as its capture starts, the file does not exist yet.
+func CreateCaptureRecording(recording *Recording, sessionID SessionID) error {
+	now := time.Now()
+
+	recording.SessionID = &sessionID
+	recording.Status = RecordingStatusRecording
+	recording.StartedAt = &now
+	recording.LastProgressAt = &now
+
+	if err := DB.Create(recording).Error; err != nil {
+		return fmt.Errorf("error creating capture recording '%s': %w", recording.Filename, err)
+	}
+
+	return nil
+}
+
+func (recording *Recording) UpdateStatus(status RecordingStatus) error {
+	if err := DB.Model(&Recording{}).
+		Where("recording_id = ?", recording.RecordingID).
+		Update("status", status).Error; err != nil {
+		return fmt.Errorf("error updating status of recording '%s' to '%s': %w", recording.Filename, status, err)
+	}
+
+	recording.Status = status
+
+	return nil
+}
+
+// UpdateProgress Stores the number of bytes written so far by a running capture.
+func (recording *Recording) UpdateProgress(bytesWritten uint64) error {
+	now := time.Now()
+
+	if err := DB.Model(&Recording{}).
+		Where("recording_id = ?", recording.RecordingID).
+		Updates(map[string]interface{}{"bytes_written": bytesWritten, "last_progress_at": now}).Error; err != nil {
+		return err
+	}
+
+	recording.BytesWritten = bytesWritten
+	recording.LastProgressAt = &now
+
+	return nil
+}
+
+func RecordingsListByStatus(statuses ...RecordingStatus) ([]*Recording, error) {
+	var recordings []*Recording
+
+	err := DB.Model(Recording{}).
+		Where("status IN ?", statuses).
+		Order("recordings.created_at asc").
+		Find(&recordings).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return recordings, nil
+}
+
+// FindRecordings All recordings of the channel in capture order.
+func (channelId ChannelID) FindRecordings(statuses ...RecordingStatus) ([]*Recording, error) {
+	var recordings []*Recording
+
+	err := DB.Model(Recording{}).
+		Where("channel_id = ? AND status IN ?", channelId, statuses).
+		Order("recordings.created_at asc").
+		Find(&recordings).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return recordings, nil
+}
+
+// FindActiveRecording The most recent part which is currently being captured for the channel.
+func (channelId ChannelID) FindActiveRecording() (*Recording, error) {
+	var recording *Recording
+
+	err := DB.Model(Recording{}).
+		Where("channel_id = ? AND status = ?", channelId, RecordingStatusRecording).
+		Order("recordings.created_at desc").
+		First(&recording).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return recording, err
+}
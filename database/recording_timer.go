@@ -0,0 +1,72 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type TimerID uint
+
+// RecordingTimer A one-off time window in which the channel is recorded regardless of its schedules.
+// An optional URL replaces the channel URL while the timer is active.
+type RecordingTimer struct {
+	TimerID   TimerID   `json:"timerId" gorm:"autoIncrement;primaryKey;column:timer_id" extensions:"!x-nullable"`
+	Channel   Channel   `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:channel_id;references:channel_id"`
+	ChannelID ChannelID `json:"channelId" gorm:"not null;default:null;index" extensions:"!x-nullable"`
+	URL       string    `json:"url" gorm:"not null;default:''" extensions:"!x-nullable"`
+	StartAt   time.Time `json:"startAt" gorm:"not null;index" extensions:"!x-nullable"`
+	EndAt     time.Time `json:"endAt" gorm:"not null;index" extensions:"!x-nullable"`
+	CreatedAt time.Time `json:"createdAt" gorm:"not null;default:current_timestamp" extensions:"!x-nullable"`
+}
+
+func (timer *RecordingTimer) Create() error {
+	if !timer.EndAt.After(timer.StartAt) {
+		return errors.New("the timer must end after it starts")
+	}
+	timer.CreatedAt = time.Now()
+	return DB.Create(timer).Error
+}
+
+func DeleteRecordingTimer(id TimerID) error {
+	if id == 0 {
+		return errors.New("invalid timer id")
+	}
+	if err := DB.Delete(&RecordingTimer{}, "timer_id = ?", id).Error; err != nil {
+		return fmt.Errorf("error deleting timer %d: %w", id, err)
+	}
+	return nil
+}
+
+func (channelId ChannelID) FindRecordingTimers() ([]*RecordingTimer, error) {
+	var timers []*RecordingTimer
+
+	err := DB.Model(&RecordingTimer{}).
+		Where("channel_id = ?", channelId).
+		Order("start_at asc").
+		Find(&timers).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return timers, nil
+}
+
+// FindActiveRecordingTimer The timer of the channel which covers the given time, nil if there is none.
+func (channelId ChannelID) FindActiveRecordingTimer(now time.Time) (*RecordingTimer, error) {
+	var timer *RecordingTimer
+
+	err := DB.Model(&RecordingTimer{}).
+		Where("channel_id = ? AND start_at <= ? AND end_at > ?", channelId, now, now).
+		Order("start_at asc").
+		First(&timer).Error
+
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+
+	return timer, err
+}
@@ -0,0 +1,74 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ScheduleID uint
+
+// Schedule A recurring time window in which the channel is polled and recorded.
+// Channels without enabled schedules are polled around the clock.
+type Schedule struct {
+	ScheduleID ScheduleID `json:"scheduleId" gorm:"autoIncrement;primaryKey;column:schedule_id" extensions:"!x-nullable"`
+	Channel    Channel    `json:"-" gorm:"constraint:OnUpdate:CASCADE,OnDelete:CASCADE;foreignKey:channel_id;references:channel_id"`
+	ChannelID  ChannelID  `json:"channelId" gorm:"not null;default:null;index" extensions:"!x-nullable"`
+	Weekdays   Weekdays   `json:"weekdays" gorm:"type:text;not null;default:''" extensions:"!x-nullable"`
+	StartTime  string     `json:"startTime" gorm:"not null" extensions:"!x-nullable"` // 15:04
+	EndTime    string     `json:"endTime" gorm:"not null" extensions:"!x-nullable"`   // 15:04, before the start if the window spans midnight
+	Timezone   string     `json:"timezone" gorm:"not null;default:''" extensions:"!x-nullable"`
+	Enabled    bool       `json:"enabled" gorm:"not null;default:true" extensions:"!x-nullable"`
+	CreatedAt  time.Time  `json:"createdAt" gorm:"not null;default:current_timestamp" extensions:"!x-nullable"`
+}
+
+func (schedule *Schedule) Create() error {
+	schedule.CreatedAt = time.Now()
+	return DB.Create(schedule).Error
+}
+
+func (schedule *Schedule) Update() error {
+	if schedule.ScheduleID == 0 {
+		return errors.New("invalid schedule id")
+	}
+
+	return DB.Model(&Schedule{}).
+		Where("schedule_id = ?", schedule.ScheduleID).
+		Select("weekdays", "start_time", "end_time", "timezone", "enabled").
+		Updates(schedule).Error
+}
+
+func FindScheduleByID(id ScheduleID) (*Schedule, error) {
+	var schedule *Schedule
+	if err := DB.Model(&Schedule{}).Where("schedule_id = ?", id).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+func DeleteSchedule(id ScheduleID) error {
+	if id == 0 {
+		return errors.New("invalid schedule id")
+	}
+	if err := DB.Delete(&Schedule{}, "schedule_id = ?", id).Error; err != nil {
+		return fmt.Errorf("error deleting schedule %d: %w", id, err)
+	}
+	return nil
+}
+
+func (channelId ChannelID) FindSchedules() ([]*Schedule, error) {
+	var schedules []*Schedule
+
+	err := DB.Model(&Schedule{}).
+		Where("channel_id = ?", channelId).
+		Order("schedule_id asc").
+		Find(&schedules).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return schedules, nil
+}
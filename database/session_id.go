@@ -0,0 +1,39 @@
+package database
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+
+	"gorm.io/gorm"
+)
+
+// SessionID Groups all recordings which belong to the same capture session.
+type SessionID string
+
+func NewSessionID() SessionID {
+	b := make([]byte, 16)
+	// crypto/rand.Read never returns an error on supported platforms.
+	_, _ = rand.Read(b)
+	return SessionID(hex.EncodeToString(b))
+}
+
+func (sessionID SessionID) String() string {
+	return string(sessionID)
+}
+
+// FindRecordings All parts of the session in capture order.
+func (sessionID SessionID) FindRecordings(statuses ...RecordingStatus) ([]*Recording, error) {
+	var recordings []*Recording
+
+	err := DB.Model(Recording{}).
+		Where("session_id = ? AND status IN ?", sessionID, statuses).
+		Order("recordings.created_at asc").
+		Find(&recordings).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	return recordings, nil
+}
@@ -17,6 +17,13 @@ type Setting struct {
 
 const (
 	ReqInterval = "req_interval"
+	// Global retention rules across all channels, 0 disables a rule.
+	RetentionCount = "retention_count"
+	RetentionSize  = "retention_size" // Gigabytes
+	RetentionDays  = "retention_days"
+	// Used space of the data disk in percent, at which the disk watchdog restricts jobs and captures.
+	DiskWarning  = "disk_warning"
+	DiskCritical = "disk_critical"
 )
 
 func InitSettings() error {
@@ -24,10 +31,62 @@ func InitSettings() error {
 		&Setting{SettingKey: ReqInterval, SettingValue: "15", SettingType: "int"}).Error; err != nil {
 		return err
 	}
+	for _, key := range []string{RetentionCount, RetentionSize, RetentionDays} {
+		if err := DB.FirstOrCreate(&Setting{SettingKey: key, SettingValue: "0", SettingType: "int"}).Error; err != nil {
+			return err
+		}
+	}
+	if err := DB.FirstOrCreate(&Setting{SettingKey: DiskWarning, SettingValue: "90", SettingType: "int"}).Error; err != nil {
+		return err
+	}
+	if err := DB.FirstOrCreate(&Setting{SettingKey: DiskCritical, SettingValue: "95", SettingType: "int"}).Error; err != nil {
+		return err
+	}
 
 	return nil
 }
 
+func SettingsList() ([]*Setting, error) {
+	var settings []*Setting
+	if err := DB.Model(&Setting{}).Order("setting_key asc").Find(&settings).Error; err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
+
+// UpdateValue Changes the value of an existing setting, the value must match the type of the setting.
+func UpdateValue(settingKey, settingValue string) error {
+	sett := Setting{}
+	if err := DB.Table("settings").First(&sett, &Setting{SettingKey: settingKey}).Error; err != nil {
+		return fmt.Errorf("unknown setting '%s': %w", settingKey, err)
+	}
+
+	switch sett.SettingType {
+	case "int":
+		if _, err := strconv.Atoi(settingValue); err != nil {
+			return fmt.Errorf("setting '%s' must be an integer: %w", settingKey, err)
+		}
+	case "bool":
+		if settingValue != "true" && settingValue != "false" {
+			return fmt.Errorf("setting '%s' must be true or false", settingKey)
+		}
+	}
+
+	return DB.Model(&Setting{}).Where("setting_key = ?", settingKey).Update("setting_value", settingValue).Error
+}
+
+// GetIntValue Same as GetValue, for settings of type int.
+func GetIntValue(settingKey string) (int, error) {
+	value, err := GetValue(settingKey)
+	if err != nil {
+		return 0, err
+	}
+	if i, ok := value.(int); ok {
+		return i, nil
+	}
+	return 0, fmt.Errorf("setting '%s' is not an integer", settingKey)
+}
+
 func GetValue(settingKey string) (interface{}, error) {
 	sett := Setting{}
 
@@ -0,0 +1,75 @@
+package database
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Weekdays Days of the week, 0 is Sunday. Stored as comma separated list.
+type Weekdays []time.Weekday
+
+func (o *Weekdays) Scan(src any) error {
+	var value string
+	switch v := src.(type) {
+	case string:
+		value = v
+	case []byte:
+		value = string(v)
+	default:
+		return errors.New("src value cannot cast to string")
+	}
+
+	days := Weekdays{}
+	for _, item := range strings.Split(value, ",") {
+		if item == "" {
+			continue
+		}
+		day, err := strconv.Atoi(item)
+		if err != nil {
+			return fmt.Errorf("invalid weekday '%s': %w", item, err)
+		}
+		days = append(days, time.Weekday(day))
+	}
+	*o = days
+
+	return nil
+}
+
+func (o Weekdays) Value() (driver.Value, error) {
+	if err := o.IsValid(); err != nil {
+		return nil, err
+	}
+
+	items := make([]string, len(o))
+	for i, day := range o {
+		items[i] = strconv.Itoa(int(day))
+	}
+
+	return strings.Join(items, ","), nil
+}
+
+func (o Weekdays) IsValid() error {
+	for _, day := range o {
+		if day < time.Sunday || day > time.Saturday {
+			return fmt.Errorf("invalid weekday: %d", day)
+		}
+	}
+	return nil
+}
+
+// Contains An empty list contains every day.
+func (o Weekdays) Contains(day time.Weekday) bool {
+	if len(o) == 0 {
+		return true
+	}
+	for _, d := range o {
+		if d == day {
+			return true
+		}
+	}
+	return false
+}
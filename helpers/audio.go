@@ -0,0 +1,185 @@
+package helpers
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/conf"
+)
+
+// AudioFormat Codec and container of an audio-only recording.
+type AudioFormat struct {
+	Codec     string // Codec name as reported by ffprobe
+	Encoder   string
+	Bitrate   string
+	Extension string
+}
+
+const (
+	// DefaultAudioCodec Used if an audio-only channel has no codec configured.
+	DefaultAudioCodec = "aac"
+	// WaveformSize Size of waveform images which replace video frames, i.e. for the live snapshot.
+	WaveformSize       = "480x270"
+	waveformStripeSize = "4096x256"
+)
+
+var audioFormats = map[string]AudioFormat{
+	"aac":  {Codec: "aac", Encoder: "aac", Bitrate: "192k", Extension: ".m4a"},
+	"opus": {Codec: "opus", Encoder: "libopus", Bitrate: "128k", Extension: ".opus"},
+	"mp3":  {Codec: "mp3", Encoder: "libmp3lame", Bitrate: "192k", Extension: ".mp3"},
+}
+
+// GetAudioFormat Returns the format of the codec, an empty codec selects AAC in an M4A container.
+func GetAudioFormat(codec string) (AudioFormat, error) {
+	if codec == "" {
+		codec = DefaultAudioCodec
+	}
+	if format, ok := audioFormats[codec]; ok {
+		return format, nil
+	}
+	return AudioFormat{}, fmt.Errorf("unsupported audio codec '%s'", codec)
+}
+
+// IsAudioFile Checks by the extension if the file is an audio-only recording.
+func IsAudioFile(path string) bool {
+	extension := strings.ToLower(filepath.Ext(path))
+	for _, format := range audioFormats {
+		if format.Extension == extension {
+			return true
+		}
+	}
+	return false
+}
+
+// OutputArgs ffmpeg arguments which write the first audio stream of the input in this format.
+// The stream is only re-encoded, if the source codec differs from the codec of the format.
+func (format AudioFormat) OutputArgs(sourceCodec string) []string {
+	args := []string{"-map", "0:a:0", "-vn"}
+	if sourceCodec == format.Codec {
+		args = append(args, "-c:a", "copy")
+	} else {
+		args = append(args, "-c:a", format.Encoder, "-b:a", format.Bitrate)
+	}
+	return append(args, containerArgs(format.Extension)...)
+}
+
+// containerArgs Muxer options depending on the extension of the output file.
+// ffmpeg rejects options which the muxer does not know, so faststart is only passed to MP4 containers.
+func containerArgs(output string) []string {
+	switch strings.ToLower(filepath.Ext(output)) {
+	case ".mp4", ".m4a", ".mov":
+		return []string{"-movflags", "faststart"}
+	}
+	return []string{}
+}
+
+type AudioConversionArgs struct {
+	OnStart                func(info CommandInfo)
+	OnErr                  func(error)
+	AbsoluteInputFilepath  string
+	AbsoluteOutputFilepath string
+	SourceCodec            string
+	Format                 AudioFormat
+	// Optional range in seconds.
+	Start, End string
+}
+
+// ConvertAudio Writes the audio of the input into the container of the format.
+func ConvertAudio(args *AudioConversionArgs) error {
+	cmdArgs := []string{"-hide_banner", "-loglevel", "error", "-y", "-i", args.AbsoluteInputFilepath}
+	if args.Start != "" {
+		cmdArgs = append(cmdArgs, "-ss", args.Start)
+	}
+	if args.End != "" {
+		cmdArgs = append(cmdArgs, "-to", args.End)
+	}
+	cmdArgs = append(cmdArgs, args.Format.OutputArgs(args.SourceCodec)...)
+	cmdArgs = append(cmdArgs, args.AbsoluteOutputFilepath)
+
+	return ExecSync(&ExecArgs{
+		Command:     "ffmpeg",
+		CommandArgs: cmdArgs,
+		OnStart:     args.OnStart,
+		OnPipeErr: func(info PipeMessage) {
+			if args.OnErr != nil {
+				args.OnErr(errors.New(info.Output))
+			}
+		},
+	})
+}
+
+// ExtractWaveform Renders the audio of the input as waveform image of the size, i.e. "480x270".
+// A duration greater than 0 only reads the beginning of the input, which is required for live streams.
+// The optional input arguments are passed before the input, i.e. HTTP headers of a stream.
+func ExtractWaveform(input, size, outputPath string, seconds uint, inputArgs ...string) error {
+	args := append([]string{"-y", "-hide_banner", "-loglevel", "error"}, inputArgs...)
+	if seconds > 0 {
+		args = append(args, "-t", fmt.Sprint(seconds))
+	}
+	args = append(args, "-i", input, "-filter_complex", fmt.Sprintf("[0:a:0]aformat=channel_layouts=mono,showwavespic=s=%s:colors=white", size), "-frames:v", "1", outputPath)
+
+	if err := ExecSync(&ExecArgs{Command: "ffmpeg", CommandArgs: args}); err != nil {
+		return fmt.Errorf("error rendering waveform '%s'", err)
+	}
+
+	return nil
+}
+
+// ExtractArtwork Writes the embedded cover art of the input, see FFProbeInfo.HasArtwork.
+func ExtractArtwork(input, width, outputPath string) error {
+	err := ExecSync(&ExecArgs{
+		Command:     "ffmpeg",
+		CommandArgs: []string{"-y", "-hide_banner", "-loglevel", "error", "-i", input, "-map", "0:v:0", "-vf", "scale=" + width + ":-1", "-q:v", "2", "-frames:v", "1", outputPath},
+	})
+
+	if err != nil {
+		return fmt.Errorf("error extracting artwork '%s'", err)
+	}
+
+	return nil
+}
+
+// ExecAudioCover The cover of an audio recording is its artwork or otherwise its waveform.
+func (video Video) ExecAudioCover(outputPath string, hasArtwork bool) (*PreviewResult, error) {
+	coverDir := filepath.Join(outputPath, CoverFolder)
+	if err := os.MkdirAll(coverDir, 0777); err != nil {
+		return nil, err
+	}
+
+	file := FileNameWithoutExtension(filepath.Base(video.FilePath)) + ".jpg"
+	path := filepath.Join(coverDir, file)
+
+	if hasArtwork {
+		err := ExtractArtwork(video.FilePath, conf.FrameWidth, path)
+		if err == nil {
+			return &PreviewResult{FilePath: video.FilePath, Filename: file}, nil
+		}
+		log.Warnf("[Audio] Falling back to waveform for '%s': %s", video.FilePath, err)
+	}
+
+	if err := ExtractWaveform(video.FilePath, WaveformSize, path, 0); err != nil {
+		return nil, fmt.Errorf("error generating poster for '%s': %s", video.FilePath, err)
+	}
+
+	return &PreviewResult{FilePath: video.FilePath, Filename: file}, nil
+}
+
+// ExecAudioStripe The timeline stripe of an audio recording is a wide waveform.
+func (video Video) ExecAudioStripe(outputPath string) (*PreviewResult, error) {
+	dir := filepath.Join(outputPath, StripesFolder)
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return nil, err
+	}
+
+	filename := FileNameWithoutExtension(filepath.Base(video.FilePath))
+
+	if err := ExtractWaveform(video.FilePath, waveformStripeSize, filepath.Join(dir, filename+".jpg"), 0); err != nil {
+		return nil, fmt.Errorf("error generating stripe for '%s': %s", video.FilePath, err)
+	}
+
+	return &PreviewResult{Filename: filepath.Base(video.FilePath), FilePath: filepath.Join(outputPath, filename+".jpg")}, nil
+}
@@ -0,0 +1,74 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAudioFormatOutputArgs(t *testing.T) {
+	format, err := GetAudioFormat("")
+	if err != nil || format.Extension != ".m4a" {
+		t.Fatalf("GetAudioFormat(\"\") is %+v, %v", format, err)
+	}
+
+	if args := strings.Join(format.OutputArgs("aac"), " "); args != "-map 0:a:0 -vn -c:a copy -movflags faststart" {
+		t.Errorf("OutputArgs(aac) is %q", args)
+	}
+
+	format, _ = GetAudioFormat("opus")
+	if args := strings.Join(format.OutputArgs("aac"), " "); args != "-map 0:a:0 -vn -c:a libopus -b:a 128k" {
+		t.Errorf("OutputArgs(aac) for opus is %q", args)
+	}
+
+	if _, err := GetAudioFormat("flac"); err == nil {
+		t.Error("GetAudioFormat(flac) should return an error")
+	}
+}
+
+func TestIsAudioFile(t *testing.T) {
+	if !IsAudioFile("/recordings/radio/show.M4A") || !IsAudioFile("show.opus") {
+		t.Error("IsAudioFile() should detect audio extensions")
+	}
+	if IsAudioFile("show.mp4") || IsAudioFile("show.ts") {
+		t.Error("IsAudioFile() should not detect video extensions")
+	}
+}
+
+func TestParseFFProbeInfoAudio(t *testing.T) {
+	data := []byte(`{
+		"streams": [
+			{"codec_type": "audio", "codec_name": "mp3", "r_frame_rate": "0/0", "nb_read_packets": "1200", "disposition": {"attached_pic": 0}},
+			{"codec_type": "video", "codec_name": "mjpeg", "width": 600, "height": 600, "r_frame_rate": "90000/1", "nb_read_packets": "1", "disposition": {"attached_pic": 1}}
+		],
+		"format": {"duration": "31.34", "size": "502000", "bit_rate": "128000"}
+	}`)
+
+	info, err := parseFFProbeInfo(data)
+	if err != nil {
+		t.Fatalf("parseFFProbeInfo() returned error: %v", err)
+	}
+	if info.AudioCodec != "mp3" || info.VideoCodec != "" || !info.HasArtwork {
+		t.Errorf("parseFFProbeInfo() is %+v", info)
+	}
+	if info.Width != 0 || info.Fps != 0 || info.PacketCount != 1200 || info.Duration != 31.34 {
+		t.Errorf("parseFFProbeInfo() is %+v", info)
+	}
+}
+
+func TestParseFFProbeInfoVideo(t *testing.T) {
+	data := []byte(`{
+		"streams": [
+			{"codec_type": "audio", "codec_name": "aac", "nb_read_packets": "900"},
+			{"codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080, "r_frame_rate": "30/1", "nb_read_packets": "600"}
+		],
+		"format": {"duration": "20.0", "size": "1000", "bit_rate": "400"}
+	}`)
+
+	info, err := parseFFProbeInfo(data)
+	if err != nil {
+		t.Fatalf("parseFFProbeInfo() returned error: %v", err)
+	}
+	if info.VideoCodec != "h264" || info.AudioCodec != "aac" || info.Width != 1920 || info.Fps != 30 || info.PacketCount != 600 {
+		t.Errorf("parseFFProbeInfo() is %+v", info)
+	}
+}
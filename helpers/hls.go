@@ -0,0 +1,93 @@
+package helpers
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	HLSPlaylistFilename = "index.m3u8"
+	HLSSegmentPattern   = "segment_%05d.ts"
+)
+
+// HLSOutputArgs Additional ffmpeg output, which writes the input as HLS event playlist into the folder.
+// The playlist keeps all segments from the start, live windows are cut out with TrimHLSPlaylist.
+func HLSOutputArgs(folder string, segmentSeconds uint) []string {
+	return []string{
+		"-c", "copy",
+		"-f", "hls",
+		"-hls_time", strconv.FormatUint(uint64(segmentSeconds), 10),
+		"-hls_list_size", "0",
+		"-hls_playlist_type", "event",
+		"-hls_flags", "independent_segments",
+		"-hls_segment_filename", filepath.Join(folder, HLSSegmentPattern),
+		filepath.Join(folder, HLSPlaylistFilename),
+	}
+}
+
+// TrimHLSPlaylist Turns an event playlist into a live playlist, which only contains the last segments.
+// The media sequence is advanced by the number of dropped segments, so players can follow the live edge.
+func TrimHLSPlaylist(playlist string, segments int) string {
+	var header, footer []string
+	var groups [][]string
+	var current []string
+	sequence := 0
+
+	for _, line := range strings.Split(playlist, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"):
+			sequence, _ = strconv.Atoi(strings.TrimPrefix(line, "#EXT-X-MEDIA-SEQUENCE:"))
+		case strings.HasPrefix(line, "#EXT-X-PLAYLIST-TYPE:"):
+		case line == "#EXT-X-ENDLIST":
+			footer = append(footer, line)
+		case strings.HasPrefix(line, "#EXTINF:"), strings.HasPrefix(line, "#EXT-X-DISCONTINUITY"), strings.HasPrefix(line, "#EXT-X-PROGRAM-DATE-TIME:"):
+			current = append(current, line)
+		case strings.HasPrefix(line, "#") && len(groups) == 0 && len(current) == 0:
+			header = append(header, line)
+		case strings.HasPrefix(line, "#"):
+			current = append(current, line)
+		default:
+			groups = append(groups, append(current, line))
+			current = nil
+		}
+	}
+
+	dropped := 0
+	if segments > 0 && len(groups) > segments {
+		dropped = len(groups) - segments
+	}
+
+	lines := append(header, fmt.Sprintf("#EXT-X-MEDIA-SEQUENCE:%d", sequence+dropped))
+	for _, group := range groups[dropped:] {
+		lines = append(lines, group...)
+	}
+	lines = append(lines, footer...)
+
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// AppendPlaylistQuery Adds the query string to each URI of the playlist, i.e. to pass on authentication to the segments.
+func AppendPlaylistQuery(playlist, query string) string {
+	if query == "" {
+		return playlist
+	}
+
+	lines := strings.Split(playlist, "\n")
+	for i, line := range lines {
+		uri := strings.TrimSpace(line)
+		if uri == "" || strings.HasPrefix(uri, "#") {
+			continue
+		}
+		separator := "?"
+		if strings.Contains(uri, "?") {
+			separator = "&"
+		}
+		lines[i] = uri + separator + query
+	}
+
+	return strings.Join(lines, "\n")
+}
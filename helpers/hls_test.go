@@ -0,0 +1,55 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAppendPlaylistQuery(t *testing.T) {
+	playlist := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4.000000,\nsegment_00001.ts\n#EXTINF:4.000000,\nsegment_00002.ts?v=1\n"
+	expected := "#EXTM3U\n#EXT-X-TARGETDURATION:4\n#EXTINF:4.000000,\nsegment_00001.ts?Authorization=abc\n#EXTINF:4.000000,\nsegment_00002.ts?v=1&Authorization=abc\n"
+
+	if result := AppendPlaylistQuery(playlist, "Authorization=abc"); result != expected {
+		t.Errorf("AppendPlaylistQuery is %q but should be %q", result, expected)
+	}
+	if result := AppendPlaylistQuery(playlist, ""); result != playlist {
+		t.Errorf("AppendPlaylistQuery with empty query changed the playlist: %q", result)
+	}
+}
+
+func TestTrimHLSPlaylist(t *testing.T) {
+	playlist := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:4
+#EXT-X-MEDIA-SEQUENCE:0
+#EXT-X-PLAYLIST-TYPE:EVENT
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXTINF:4.000000,
+segment_00000.ts
+#EXTINF:4.000000,
+segment_00001.ts
+#EXT-X-DISCONTINUITY
+#EXTINF:4.000000,
+segment_00002.ts
+#EXTINF:3.500000,
+segment_00003.ts
+`
+	expected := `#EXTM3U
+#EXT-X-VERSION:6
+#EXT-X-TARGETDURATION:4
+#EXT-X-INDEPENDENT-SEGMENTS
+#EXT-X-MEDIA-SEQUENCE:2
+#EXT-X-DISCONTINUITY
+#EXTINF:4.000000,
+segment_00002.ts
+#EXTINF:3.500000,
+segment_00003.ts
+`
+	if result := TrimHLSPlaylist(playlist, 2); result != expected {
+		t.Errorf("TrimHLSPlaylist is\n%s\nbut should be\n%s", result, expected)
+	}
+
+	if result := TrimHLSPlaylist(playlist+"#EXT-X-ENDLIST\n", 10); !strings.HasSuffix(result, "segment_00003.ts\n#EXT-X-ENDLIST\n") || !strings.Contains(result, "#EXT-X-MEDIA-SEQUENCE:0\n#EXTINF") {
+		t.Errorf("TrimHLSPlaylist with fewer segments than the window is\n%s", result)
+	}
+}
@@ -39,6 +39,12 @@ type CutArgs struct {
 	DeleteAfterCompletion bool     `json:"deleteAfterCut"`
 }
 
+// ClipArgs Range in seconds of a recording which is still being captured.
+type ClipArgs struct {
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+}
+
 type TaskProgress struct {
 	Current uint64 `json:"current"`
 	Total   uint64 `json:"total"`
@@ -80,10 +86,15 @@ type ProcessInfo struct {
 
 type JSONFFProbeInfo struct {
 	Streams []struct {
+		CodecType   string `json:"codec_type"`
+		CodecName   string `json:"codec_name"`
 		Width       uint   `json:"width"`
 		Height      uint   `json:"height"`
 		RFrameRate  string `json:"r_frame_rate"`
 		PacketCount string `json:"nb_read_packets"`
+		Disposition struct {
+			AttachedPic int `json:"attached_pic"`
+		} `json:"disposition"`
 	} `json:"streams"`
 	Format struct {
 		Duration string `json:"duration"`
@@ -100,6 +111,9 @@ type FFProbeInfo struct {
 	Width       uint
 	Height      uint
 	PacketCount uint64
+	VideoCodec  string
+	AudioCodec  string
+	HasArtwork  bool
 }
 
 type ConversionResult struct {
@@ -132,6 +146,13 @@ type PreviewVideoArgs struct {
 	FrameDistance, FrameHeight uint
 }
 
+type RemuxArgs struct {
+	OnStart                func(info CommandInfo)
+	OnErr                  func(error)
+	AbsoluteInputFilepath  string
+	AbsoluteOutputFilepath string
+}
+
 type MergeArgs struct {
 	OnStart                func(info CommandInfo)
 	OnProgress             func(info PipeMessage)
@@ -271,10 +292,14 @@ func calcFps(output string) (float64, error) {
 	return fps, nil
 }
 
-func ExtractFirstFrame(input, height, outputPathPoster string) error {
+// ExtractFirstFrame The optional input arguments are passed before the input, i.e. HTTP headers of a stream.
+func ExtractFirstFrame(input, height, outputPathPoster string, inputArgs ...string) error {
+	args := append([]string{"-y", "-hide_banner", "-loglevel", "error"}, inputArgs...)
+	args = append(args, "-i", input, "-r", "1", "-vf", "scale="+height+":-1", "-q:v", "2", "-frames:v", "1", outputPathPoster)
+
 	err := ExecSync(&ExecArgs{
 		Command:     "ffmpeg",
-		CommandArgs: []string{"-y", "-hide_banner", "-loglevel", "error", "-i", input, "-r", "1", "-vf", "scale=" + height + ":-1", "-q:v", "2", "-frames:v", "1", outputPathPoster},
+		CommandArgs: args,
 	})
 
 	if err != nil {
@@ -522,8 +547,9 @@ func (video Video) ExecPreviewCover(outputPath string) (*PreviewResult, error) {
 //}
 
 // GetVideoInfo Generate file information via ffprobe in JSON and parses it from stout.
+// Audio-only files have no video stream, their size and frame rate are 0.
 func (video *Video) GetVideoInfo() (*FFProbeInfo, error) {
-	cmd := exec.Command("ffprobe", "-i", video.FilePath, "-show_entries", "format=bit_rate,size,duration", "-show_entries", "stream=r_frame_rate,width,height,nb_read_packets", "-v", "error", "-select_streams", "v:0", "-count_packets", "-of", "default=noprint_wrappers=1", "-print_format", "json")
+	cmd := exec.Command("ffprobe", "-i", video.FilePath, "-show_entries", "format=bit_rate,size,duration:stream=codec_type,codec_name,r_frame_rate,width,height,nb_read_packets:stream_disposition=attached_pic", "-v", "error", "-count_packets", "-of", "default=noprint_wrappers=1", "-print_format", "json")
 	stdout, err := cmd.CombinedOutput()
 	output := strings.TrimSpace(string(stdout))
 
@@ -531,9 +557,12 @@ func (video *Video) GetVideoInfo() (*FFProbeInfo, error) {
 		return nil, fmt.Errorf("error ffprobe: %s: %s", err, output)
 	}
 
+	return parseFFProbeInfo([]byte(output))
+}
+
+func parseFFProbeInfo(data []byte) (*FFProbeInfo, error) {
 	parsed := &JSONFFProbeInfo{}
-	err = json.Unmarshal([]byte(output), &parsed)
-	if err != nil {
+	if err := json.Unmarshal(data, &parsed); err != nil {
 		return nil, err
 	}
 
@@ -565,20 +594,52 @@ func (video *Video) GetVideoInfo() (*FFProbeInfo, error) {
 	}
 	info.Size = size
 
-	fps, err := calcFps(parsed.Streams[0].RFrameRate)
+	// Cover art is stored as a video stream with a single picture.
+	videoStream, audioStream := -1, -1
+	for i, stream := range parsed.Streams {
+		switch {
+		case stream.CodecType == "video" && stream.Disposition.AttachedPic == 1:
+			info.HasArtwork = true
+		case stream.CodecType == "video" && videoStream < 0:
+			videoStream = i
+		case stream.CodecType == "audio" && audioStream < 0:
+			audioStream = i
+		}
+	}
+
+	if audioStream >= 0 {
+		info.AudioCodec = parsed.Streams[audioStream].CodecName
+	}
+
+	if videoStream < 0 {
+		if audioStream < 0 {
+			return info, errors.New("ffprobe found neither video nor audio stream")
+		}
+		packets, err := strconv.ParseUint(parsed.Streams[audioStream].PacketCount, 10, 64)
+		if err != nil {
+			return info, err
+		}
+		info.PacketCount = packets
+		return info, nil
+	}
+
+	stream := parsed.Streams[videoStream]
+	info.VideoCodec = stream.CodecName
+
+	fps, err := calcFps(stream.RFrameRate)
 	if err != nil {
 		return info, err
 	}
 	info.Fps = fps
 
-	packets, err := strconv.ParseUint(parsed.Streams[0].PacketCount, 10, 64)
+	packets, err := strconv.ParseUint(stream.PacketCount, 10, 64)
 	if err != nil {
 		return info, err
 	}
 	info.PacketCount = packets
 
-	info.Width = parsed.Streams[0].Width
-	info.Height = parsed.Streams[0].Height
+	info.Width = stream.Width
+	info.Height = stream.Height
 
 	return info, nil
 }
@@ -591,7 +652,7 @@ func MergeVideos(args *MergeArgs) error {
 
 	return ExecSync(&ExecArgs{
 		Command:     "ffmpeg",
-		CommandArgs: []string{"-hide_banner", "-loglevel", "error", "-f", "concat", "-safe", "0", "-i", args.MergeFileAbsolutePath, "-movflags", "faststart", "-codec", "copy", args.AbsoluteOutputFilepath},
+		CommandArgs: append(append([]string{"-hide_banner", "-loglevel", "error", "-f", "concat", "-safe", "0", "-i", args.MergeFileAbsolutePath}, containerArgs(args.AbsoluteOutputFilepath)...), "-codec", "copy", args.AbsoluteOutputFilepath),
 		OnStart:     args.OnStart,
 		OnPipeErr: func(info PipeMessage) {
 			if args.OnErr != nil {
@@ -602,6 +663,20 @@ func MergeVideos(args *MergeArgs) error {
 	})
 }
 
+// RemuxVideo Copies all streams without re-encoding into a faststart mp4 container.
+func RemuxVideo(args *RemuxArgs) error {
+	return ExecSync(&ExecArgs{
+		Command:     "ffmpeg",
+		CommandArgs: append(append([]string{"-hide_banner", "-loglevel", "error", "-y", "-i", args.AbsoluteInputFilepath}, containerArgs(args.AbsoluteOutputFilepath)...), "-codec", "copy", args.AbsoluteOutputFilepath),
+		OnStart:     args.OnStart,
+		OnPipeErr: func(info PipeMessage) {
+			if args.OnErr != nil {
+				args.OnErr(errors.New(info.Output))
+			}
+		},
+	})
+}
+
 func CutVideo(args *CuttingJob, absoluteFilepath, absoluteOutputFilepath, startIntervals, endIntervals string) error {
 	log.Infoln("---------------------------------------------- Cutting Job ----------------------------------------------")
 	log.Infoln(absoluteFilepath)
@@ -612,7 +687,7 @@ func CutVideo(args *CuttingJob, absoluteFilepath, absoluteOutputFilepath, startI
 
 	return ExecSync(&ExecArgs{
 		Command:     "ffmpeg",
-		CommandArgs: []string{"-progress", "pipe:1", "-hide_banner", "-loglevel", "error", "-i", absoluteFilepath, "-ss", startIntervals, "-to", endIntervals, "-movflags", "faststart", "-codec", "copy", absoluteOutputFilepath},
+		CommandArgs: append(append([]string{"-progress", "pipe:1", "-hide_banner", "-loglevel", "error", "-i", absoluteFilepath, "-ss", startIntervals, "-to", endIntervals}, containerArgs(absoluteOutputFilepath)...), "-codec", "copy", absoluteOutputFilepath),
 		OnStart: func(info CommandInfo) {
 			args.OnStart(&info)
 		},
@@ -69,8 +69,10 @@ func main() {
     setupFolders()
 
     services.StartUpJobs()
+    services.StartDiskWatchdog()
     services.StartRecorder()
     services.StartJobProcessing()
+    services.StartRetention()
 
     gin.SetMode("release")
     endPoint := fmt.Sprintf("0.0.0.0:%d", 3000)
@@ -98,6 +100,8 @@ func main() {
 func cleanup() {
     log.Infoln("cleanup ...")
     services.StopJobProcessing()
+    services.StopRetention()
+    services.StopDiskWatchdog()
     services.StopRecorder()
     log.Infoln("cleanup complete")
 }
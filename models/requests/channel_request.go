@@ -12,4 +12,43 @@ type ChannelRequest struct {
 	Tags        *database.Tags `json:"tags"`
 	Fav         bool           `json:"fav"`
 	Deleted     bool           `json:"deleted"`
+
+	SegmentDuration uint `json:"segmentDuration" extensions:"!x-nullable"`
+	SegmentSize     uint `json:"segmentSize" extensions:"!x-nullable"`
+
+	ReconnectGrace uint `json:"reconnectGrace" extensions:"!x-nullable"`
+	AutoMerge      bool `json:"autoMerge" extensions:"!x-nullable"`
+
+	Resolver       string `json:"resolver" extensions:"!x-nullable"`
+	FormatSelector string `json:"formatSelector" extensions:"!x-nullable"`
+	ResolverScript string `json:"resolverScript" extensions:"!x-nullable"`
+
+	MaxResolution uint             `json:"maxResolution" extensions:"!x-nullable"`
+	Headers       database.Headers `json:"headers"`
+	UserAgent     string           `json:"userAgent" extensions:"!x-nullable"`
+	CookiesFile   string           `json:"cookiesFile" extensions:"!x-nullable"`
+	Proxy         string           `json:"proxy" extensions:"!x-nullable"`
+
+	AudioOnly  bool   `json:"audioOnly" extensions:"!x-nullable"`
+	AudioCodec string `json:"audioCodec" extensions:"!x-nullable"`
+
+	Type        database.ChannelType `json:"type" extensions:"!x-nullable"`
+	SplitTracks bool                 `json:"splitTracks" extensions:"!x-nullable"`
+
+	IngestProtocol database.IngestProtocol `json:"ingestProtocol" extensions:"!x-nullable"`
+	IngestPort     uint                    `json:"ingestPort" extensions:"!x-nullable"`
+	StreamKey      string                  `json:"streamKey" extensions:"!x-nullable"`
+
+	Backfill uint `json:"backfill" extensions:"!x-nullable"`
+
+	RingQuota uint `json:"ringQuota" extensions:"!x-nullable"`
+
+	CaptureRules database.CaptureRules `json:"captureRules" extensions:"!x-nullable"`
+
+	RetentionCount uint `json:"retentionCount" extensions:"!x-nullable"`
+	RetentionSize  uint `json:"retentionSize" extensions:"!x-nullable"`
+	RetentionDays  uint `json:"retentionDays" extensions:"!x-nullable"`
+
+	StallTimeout         uint `json:"stallTimeout" extensions:"!x-nullable"`
+	DisableStallWatchdog bool `json:"disableStallWatchdog" extensions:"!x-nullable"`
 }
@@ -0,0 +1,8 @@
+package requests
+
+// ClipRequest Offsets in seconds before the live edge of the capture. If set, the duration replaces the start offset.
+type ClipRequest struct {
+	Start    uint `json:"start" extensions:"!x-nullable"`
+	End      uint `json:"end" extensions:"!x-nullable"`
+	Duration uint `json:"duration" extensions:"!x-nullable"`
+}
@@ -0,0 +1,6 @@
+package requests
+
+// FetchRequest A video page or a link to a media file, which is downloaded into the channel.
+type FetchRequest struct {
+	URL string `json:"url" extensions:"!x-nullable"`
+}
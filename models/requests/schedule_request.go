@@ -0,0 +1,21 @@
+package requests
+
+import (
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+type ScheduleRequest struct {
+	Weekdays  database.Weekdays `json:"weekdays"`
+	StartTime string            `json:"startTime" extensions:"!x-nullable"`
+	EndTime   string            `json:"endTime" extensions:"!x-nullable"`
+	Timezone  string            `json:"timezone" extensions:"!x-nullable"`
+	Enabled   bool              `json:"enabled" extensions:"!x-nullable"`
+}
+
+type RecordingTimerRequest struct {
+	URL      string    `json:"url" extensions:"!x-nullable"`
+	StartAt  time.Time `json:"startAt" extensions:"!x-nullable"`
+	Duration uint      `json:"duration" extensions:"!x-nullable"` // Minutes
+}
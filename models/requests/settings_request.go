@@ -0,0 +1,6 @@
+package requests
+
+type SettingRequest struct {
+	SettingKey   string `json:"settingKey" extensions:"!x-nullable"`
+	SettingValue string `json:"settingValue" extensions:"!x-nullable"`
+}
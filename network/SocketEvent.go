@@ -17,6 +17,8 @@ const (
 	ChannelOfflineEvent   SocketEventName = "channel:offline"
 	ChannelStartEvent     SocketEventName = "channel:start"
 	ChannelThumbnailEvent SocketEventName = "channel:thumbnail"
+	ChannelStalledEvent   SocketEventName = "channel:stalled"
+	ChannelProgressEvent  SocketEventName = "channel:progress"
 
 	JobCreateEvent      SocketEventName = "job:create"
 	JobStartEvent       SocketEventName = "job:start"
@@ -28,7 +30,11 @@ const (
 	JobPreviewDoneEvent SocketEventName = "job:preview:done"
 	JobDeleteEvent      SocketEventName = "job:delete"
 
-	RecordingAddEvent SocketEventName = "recording:add"
+	RecordingAddEvent    SocketEventName = "recording:add"
+	RecordingStatusEvent SocketEventName = "recording:status"
+	RecordingDeleteEvent SocketEventName = "recording:delete"
+
+	SystemDiskEvent SocketEventName = "system:disk"
 )
 
 var (
@@ -0,0 +1,33 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// DirectResolver Passes the channel URL to ffmpeg as it is, i.e. for HLS playlists or RTMP/RTSP sources.
+type DirectResolver struct{}
+
+var directSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+	"rtmp":  true,
+	"rtmps": true,
+	"rtsp":  true,
+	"rtsps": true,
+	"srt":   true,
+	"udp":   true,
+}
+
+func (r *DirectResolver) Resolve(_ context.Context, request Request) (*Result, error) {
+	parsed, err := url.Parse(request.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid stream url '%s': %w", request.URL, err)
+	}
+	if !directSchemes[parsed.Scheme] {
+		return nil, fmt.Errorf("unsupported scheme '%s' of stream url '%s'", parsed.Scheme, request.URL)
+	}
+
+	return &Result{URL: request.URL, IsLive: true}, nil
+}
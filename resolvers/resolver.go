@@ -0,0 +1,186 @@
+package resolvers
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Name Identifies a resolver implementation, it is stored per channel.
+type Name string
+
+const (
+	YtDlp  Name = "yt-dlp"
+	Direct Name = "direct"
+	Script Name = "script"
+)
+
+// Request Everything a resolver needs to know about the channel.
+type Request struct {
+	URL string
+	// Format Resolver specific format selector, i.e. "best" for yt-dlp.
+	Format string
+	// Script Absolute path of the executable used by the script resolver.
+	Script string
+	// MaxResolution Maximum height of the selected format, 0 is unlimited.
+	MaxResolution uint
+	// Headers Sent with every request, they take precedence over the headers of the resolver.
+	Headers map[string]string
+	// Cookies Absolute path of a cookies file in the Netscape format.
+	Cookies string
+	// Proxy URL of an HTTP or SOCKS proxy.
+	Proxy string
+}
+
+// Result Structured information about a stream which can be passed to ffmpeg.
+type Result struct {
+	URL     string            `json:"url"`
+	Headers map[string]string `json:"headers"`
+	Title   string            `json:"title"`
+	IsLive  bool              `json:"isLive"`
+	// Cookies In the Set-Cookie format, one cookie per line.
+	Cookies string `json:"cookies"`
+	Proxy   string `json:"proxy"`
+
+	// Metadata of the broadcast, resolvers which don't know it leave it empty.
+	Description  string `json:"description"`
+	Category     string `json:"category"`
+	Uploader     string `json:"uploader"`
+	ThumbnailURL string `json:"thumbnailUrl"`
+	OriginalID   string `json:"originalId"` // Id of the broadcast on the site
+	Height       uint   `json:"height"`     // Of the selected format, 0 if unknown
+}
+
+// StreamResolver Turns the URL of a channel into the actual media URL of the stream.
+// An offline stream must be reported as error.
+type StreamResolver interface {
+	Resolve(ctx context.Context, request Request) (*Result, error)
+}
+
+var registry = map[Name]StreamResolver{
+	YtDlp:  &YtDlpResolver{},
+	Direct: &DirectResolver{},
+	Script: &ScriptResolver{},
+}
+
+// Get Returns the resolver by its name, an empty name selects yt-dlp.
+func Get(name Name) (StreamResolver, error) {
+	if name == "" {
+		name = YtDlp
+	}
+	if resolver, ok := registry[name]; ok {
+		return resolver, nil
+	}
+	return nil, fmt.Errorf("unknown stream resolver '%s'", name)
+}
+
+// Resolve Queries the stream with the named resolver and applies the headers, cookies and proxy of the request,
+// so that ffmpeg fetches the stream the same way the resolver did. The cookies file is passed on, unless the
+// resolver returned the cookies itself.
+func Resolve(ctx context.Context, name Name, request Request) (*Result, error) {
+	resolver, err := Get(name)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := resolver.Resolve(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(request.Headers) > 0 {
+		headers := make(map[string]string, len(result.Headers)+len(request.Headers))
+		for key, value := range result.Headers {
+			headers[key] = value
+		}
+		for key, value := range request.Headers {
+			headers[key] = value
+		}
+		result.Headers = headers
+	}
+	if request.Proxy != "" {
+		result.Proxy = request.Proxy
+	}
+	if result.Cookies == "" && request.Cookies != "" {
+		if result.Cookies, err = readCookiesFile(request.Cookies, time.Now()); err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+// InputArgs ffmpeg arguments which must precede the input, i.e. the HTTP headers required by the stream.
+// ffmpeg only supports HTTP proxies, channels which are captured by ffmpeg don't accept other proxies.
+func (result *Result) InputArgs() []string {
+	args := []string{}
+	// Cameras are mostly behind NAT, where RTP over UDP loses packets.
+	if strings.HasPrefix(result.URL, "rtsp://") || strings.HasPrefix(result.URL, "rtsps://") {
+		return append(args, "-rtsp_transport", "tcp")
+	}
+	if !isHTTP(result.URL) {
+		return args
+	}
+
+	if len(result.Headers) > 0 {
+		args = append(args, "-headers", result.headerLines())
+	}
+	if result.Cookies != "" {
+		args = append(args, "-cookies", result.Cookies)
+	}
+	if strings.HasPrefix(result.Proxy, "http://") {
+		args = append(args, "-http_proxy", result.Proxy)
+	}
+
+	return args
+}
+
+func (result *Result) headerLines() string {
+	keys := make([]string, 0, len(result.Headers))
+	for key := range result.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var headers strings.Builder
+	for _, key := range keys {
+		headers.WriteString(fmt.Sprintf("%s: %s\r\n", key, result.Headers[key]))
+	}
+
+	return headers.String()
+}
+
+// readCookiesFile Converts the unexpired cookies of a file in the Netscape format into the Set-Cookie format of ffmpeg.
+func readCookiesFile(path string, now time.Time) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("error reading cookies file: %w", err)
+	}
+
+	var cookies []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(strings.TrimPrefix(line, "#HttpOnly_"))
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		// domain, include subdomains, path, secure, expiry, name, value
+		fields := strings.Split(line, "\t")
+		if len(fields) != 7 {
+			continue
+		}
+		if expiry, err := strconv.ParseInt(fields[4], 10, 64); err == nil && expiry > 0 && time.Unix(expiry, 0).Before(now) {
+			continue
+		}
+		cookies = append(cookies, fmt.Sprintf("%s=%s; path=%s; domain=%s", fields[5], fields[6], fields[2], fields[0]))
+	}
+
+	return strings.Join(cookies, "\n"), nil
+}
+
+func isHTTP(url string) bool {
+	return strings.HasPrefix(url, "http://") || strings.HasPrefix(url, "https://")
+}
@@ -0,0 +1,207 @@
+package resolvers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseYtDlpInfo(t *testing.T) {
+	data := []byte(`{"title": "Live", "is_live": true, "url": "https://example.com/live.m3u8", "http_headers": {"User-Agent": "agent"}}`)
+
+	result, err := parseYtDlpInfo(data)
+	if err != nil {
+		t.Fatalf("parseYtDlpInfo() returned error: %v", err)
+	}
+	if result.URL != "https://example.com/live.m3u8" || result.Title != "Live" || !result.IsLive {
+		t.Errorf("parseYtDlpInfo() is %+v", result)
+	}
+	if result.Headers["User-Agent"] != "agent" {
+		t.Errorf("parseYtDlpInfo() headers are %v", result.Headers)
+	}
+}
+
+func TestParseYtDlpInfoMetadata(t *testing.T) {
+	data := []byte(`{"id": "v123", "title": "Speedrun", "description": "Any%", "categories": ["Gaming", "Retro"], "channel": "runner", "thumbnail": "https://example.com/thumb.jpg", "url": "https://example.com/live.m3u8", "height": 1080}`)
+
+	result, err := parseYtDlpInfo(data)
+	if err != nil {
+		t.Fatalf("parseYtDlpInfo() returned error: %v", err)
+	}
+	if result.OriginalID != "v123" || result.Description != "Any%" || result.Category != "Gaming" || result.ThumbnailURL != "https://example.com/thumb.jpg" || result.Height != 1080 {
+		t.Errorf("parseYtDlpInfo() is %+v", result)
+	}
+	// The channel is the uploader of extractors without one.
+	if result.Uploader != "runner" {
+		t.Errorf("uploader is %q", result.Uploader)
+	}
+}
+
+func TestParseYtDlpInfoRequestedFormats(t *testing.T) {
+	data := []byte(`{"title": "Live", "live_status": "is_live", "requested_formats": [{"url": "https://example.com/video"}, {"url": "https://example.com/audio"}]}`)
+
+	result, err := parseYtDlpInfo(data)
+	if err != nil {
+		t.Fatalf("parseYtDlpInfo() returned error: %v", err)
+	}
+	if result.URL != "https://example.com/video" || !result.IsLive {
+		t.Errorf("parseYtDlpInfo() is %+v", result)
+	}
+
+	if _, err := parseYtDlpInfo([]byte(`{"title": "Offline"}`)); err == nil {
+		t.Error("parseYtDlpInfo() without url should return an error")
+	}
+}
+
+func TestParseScriptOutput(t *testing.T) {
+	result, err := parseScriptOutput([]byte("https://example.com/a.m3u8\nsome log\n"))
+	if err != nil || result.URL != "https://example.com/a.m3u8" {
+		t.Errorf("parseScriptOutput() is %+v, %v", result, err)
+	}
+
+	result, err = parseScriptOutput([]byte(`{"url": "rtmp://example.com/live", "title": "Show", "isLive": true}`))
+	if err != nil || result.URL != "rtmp://example.com/live" || result.Title != "Show" {
+		t.Errorf("parseScriptOutput() is %+v, %v", result, err)
+	}
+
+	if _, err := parseScriptOutput([]byte("  \n")); err == nil {
+		t.Error("parseScriptOutput() with empty output should return an error")
+	}
+}
+
+func TestDirectResolver(t *testing.T) {
+	resolver, err := Get(Direct)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := resolver.Resolve(context.Background(), Request{URL: "rtsp://camera.local/stream"}); err != nil {
+		t.Errorf("Resolve() returned error: %v", err)
+	}
+	if _, err := resolver.Resolve(context.Background(), Request{URL: "file:///etc/passwd"}); err == nil {
+		t.Error("Resolve() should reject file urls")
+	}
+}
+
+func TestInputArgs(t *testing.T) {
+	result := &Result{URL: "https://example.com/live.m3u8", Headers: map[string]string{"Referer": "https://example.com", "Cookie": "a=b"}}
+
+	args := result.InputArgs()
+	if len(args) != 2 || args[0] != "-headers" || args[1] != "Cookie: a=b\r\nReferer: https://example.com\r\n" {
+		t.Errorf("InputArgs() is %q", args)
+	}
+
+	result.URL = "rtmp://example.com/live"
+	if args := result.InputArgs(); len(args) != 0 {
+		t.Errorf("InputArgs() for rtmp is %q", args)
+	}
+
+	result.URL = "rtsp://camera.local:554/stream1"
+	if args := result.InputArgs(); len(args) != 2 || args[0] != "-rtsp_transport" || args[1] != "tcp" {
+		t.Errorf("InputArgs() for rtsp is %q", args)
+	}
+}
+
+func TestInputArgsCookiesAndProxy(t *testing.T) {
+	result := &Result{URL: "https://example.com/live.m3u8", Cookies: "a=b; path=/", Proxy: "http://proxy:3128"}
+
+	args := result.InputArgs()
+	if len(args) != 4 || args[0] != "-cookies" || args[1] != "a=b; path=/" || args[2] != "-http_proxy" || args[3] != "http://proxy:3128" {
+		t.Errorf("InputArgs() is %q", args)
+	}
+
+	result.Proxy = "socks5://proxy:1080"
+	if args := result.InputArgs(); len(args) != 2 {
+		t.Errorf("InputArgs() with socks proxy is %q", args)
+	}
+}
+
+func TestYtDlpArgs(t *testing.T) {
+	args := strings.Join(ytDlpArgs(Request{
+		URL:           "https://example.com/channel",
+		MaxResolution: 720,
+		Headers:       map[string]string{"User-Agent": "agent", "Referer": "https://example.com"},
+		Cookies:       "/data/cookies/site.txt",
+		Proxy:         "socks5://proxy:1080",
+	}), " ")
+
+	expected := "--force-ipv4 --no-warnings --no-playlist --youtube-skip-dash-manifest -f best -S res:720 --proxy socks5://proxy:1080 --cookies /data/cookies/site.txt --add-header Referer:https://example.com --add-header User-Agent:agent --dump-single-json https://example.com/channel"
+	if args != expected {
+		t.Errorf("ytDlpArgs() is %q", args)
+	}
+}
+
+func TestParsePlaylist(t *testing.T) {
+	data := []byte(`{"extractor_key": "Youtube", "entries": [
+		{"_type": "url", "id": "abc", "ie_key": "Youtube", "url": "https://www.youtube.com/watch?v=abc", "title": "First", "timestamp": 1709618400},
+		{"_type": "url", "id": "def", "url": "def", "webpage_url": "https://example.com/def", "title": "Second", "upload_date": "20240304"},
+		{"_type": "playlist", "id": "shorts", "url": "https://www.youtube.com/@channel/shorts"},
+		{"_type": "url", "url": "https://example.com/without-id"}
+	]}`)
+
+	entries, err := parsePlaylist(data)
+	if err != nil {
+		t.Fatalf("parsePlaylist() returned error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("parsePlaylist() is %+v", entries)
+	}
+	if entries[0].ArchiveID() != "youtube abc" || entries[0].Link() != "https://www.youtube.com/watch?v=abc" || entries[0].UploadedAt() == nil {
+		t.Errorf("first entry is %+v", entries[0])
+	}
+	// The extractor of the playlist applies to entries without one.
+	if entries[1].ArchiveID() != "youtube def" || entries[1].Link() != "https://example.com/def" {
+		t.Errorf("second entry is %+v", entries[1])
+	}
+	if uploaded := entries[1].UploadedAt(); uploaded == nil || uploaded.Format("2006-01-02") != "2024-03-04" {
+		t.Errorf("upload date is %v", uploaded)
+	}
+}
+
+func TestParseProgress(t *testing.T) {
+	if downloaded, total, ok := parseProgress("[mediasink] 1024 4096.5"); !ok || downloaded != 1024 || total != 4096 {
+		t.Errorf("parseProgress() is %d, %d, %t", downloaded, total, ok)
+	}
+	if downloaded, total, ok := parseProgress("[mediasink] 1024 NA"); !ok || downloaded != 1024 || total != 0 {
+		t.Errorf("parseProgress() with unknown total is %d, %d, %t", downloaded, total, ok)
+	}
+	if _, _, ok := parseProgress("[download] Destination: video.mp4"); ok {
+		t.Error("parseProgress() should ignore other lines")
+	}
+}
+
+func TestDownloadArgs(t *testing.T) {
+	args := strings.Join(downloadArgs(Request{URL: "https://example.com/watch?v=abc", Proxy: "socks5://proxy:1080"}, "/recordings/channel/channel_item_1.mp4"), " ")
+
+	expected := "--no-warnings --no-playlist --newline --progress --progress-template download:[mediasink] %(progress.downloaded_bytes)s %(progress.total_bytes,progress.total_bytes_estimate)s -f bv*+ba/b --merge-output-format mp4 --remux-video mp4 -o /recordings/channel/channel_item_1.%(ext)s --proxy socks5://proxy:1080 --dump-json --no-simulate https://example.com/watch?v=abc"
+	if args != expected {
+		t.Errorf("downloadArgs() is %q", args)
+	}
+}
+
+func TestResolveCookiesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cookies.txt")
+	content := "# Netscape HTTP Cookie File\n" +
+		".example.com\tTRUE\t/\tTRUE\t0\tsession\tabc\n" +
+		"#HttpOnly_example.com\tFALSE\t/live\tFALSE\t4102444800\ttoken\txyz\n" +
+		"example.com\tFALSE\t/\tFALSE\t946684800\texpired\t1\n"
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	// The direct resolver returns no cookies, ffmpeg gets the ones of the file.
+	result, err := Resolve(context.Background(), Direct, Request{URL: "https://example.com/live.m3u8", Cookies: path})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := "session=abc; path=/; domain=.example.com\ntoken=xyz; path=/live; domain=example.com"
+	if result.Cookies != expected {
+		t.Errorf("cookies are %q, want %q", result.Cookies, expected)
+	}
+
+	if _, err := Resolve(context.Background(), Direct, Request{URL: "https://example.com/live.m3u8", Cookies: path + ".missing"}); err == nil {
+		t.Error("Resolve() should fail for a missing cookies file")
+	}
+}
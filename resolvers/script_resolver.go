@@ -0,0 +1,65 @@
+package resolvers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// ScriptResolver Runs an external executable with the channel URL and format selector as arguments.
+// The executable prints either a JSON object of the form {"url", "headers", "title", "isLive"}
+// or just the stream URL on its first line. A non-zero exit code means the stream is offline.
+// The proxy of the channel is passed in the usual environment variables.
+type ScriptResolver struct{}
+
+func (r *ScriptResolver) Resolve(ctx context.Context, request Request) (*Result, error) {
+	if request.Script == "" {
+		return nil, errors.New("no resolver script configured")
+	}
+
+	cmd := exec.CommandContext(ctx, request.Script, request.URL, request.Format)
+	if request.Proxy != "" {
+		cmd.Env = append(os.Environ(), "HTTP_PROXY="+request.Proxy, "HTTPS_PROXY="+request.Proxy, "ALL_PROXY="+request.Proxy)
+	}
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("resolver script timed out for URL %s", request.URL)
+		}
+		return nil, fmt.Errorf("resolver script failed for URL %s: %v\nOutput: %s", request.URL, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseScriptOutput(stdout.Bytes())
+}
+
+func parseScriptOutput(data []byte) (*Result, error) {
+	output := strings.TrimSpace(string(data))
+
+	if strings.HasPrefix(output, "{") {
+		var result Result
+		if err := json.Unmarshal([]byte(output), &result); err != nil {
+			return nil, fmt.Errorf("error parsing resolver script output: %w", err)
+		}
+		if result.URL == "" {
+			return nil, errors.New("resolver script returned no stream url")
+		}
+		return &result, nil
+	}
+
+	line, _, _ := strings.Cut(output, "\n")
+	line = strings.TrimSpace(line)
+	if line == "" {
+		return nil, errors.New("resolver script returned no stream url")
+	}
+
+	return &Result{URL: line, IsLive: true}, nil
+}
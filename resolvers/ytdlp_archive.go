@@ -0,0 +1,216 @@
+package resolvers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defaultDownloadFormat The best video and audio streams, merged into one file, or the best single file.
+const defaultDownloadFormat = "bv*+ba/b"
+
+// progressPrefix Marks the progress lines of yt-dlp, see downloadArgs.
+const progressPrefix = "[mediasink] "
+
+// PlaylistEntry A video of a playlist or uploads page, as listed by yt-dlp without visiting the video itself.
+type PlaylistEntry struct {
+	Type       string `json:"_type"`
+	ID         string `json:"id"`
+	Extractor  string `json:"ie_key"`
+	URL        string `json:"url"`
+	WebpageURL string `json:"webpage_url"`
+	Title      string `json:"title"`
+	UploadDate string `json:"upload_date"`
+	Timestamp  int64  `json:"timestamp"`
+}
+
+type ytDlpPlaylist struct {
+	Extractor string          `json:"extractor_key"`
+	Entries   []PlaylistEntry `json:"entries"`
+}
+
+// VideoInfo Metadata of a downloaded video, as printed by yt-dlp.
+type VideoInfo struct {
+	ID          string `json:"id"`
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	UploadDate  string `json:"upload_date"` // YYYYMMDD
+	Timestamp   int64  `json:"timestamp"`
+	Extension   string `json:"ext"`
+}
+
+// ArchiveID Identifies the video like the download archive of yt-dlp: "<extractor> <id>".
+func (entry PlaylistEntry) ArchiveID() string {
+	return strings.ToLower(entry.Extractor) + " " + entry.ID
+}
+
+// Link The URL of the video, flat playlists of some sites only contain the id as URL.
+func (entry PlaylistEntry) Link() string {
+	if entry.WebpageURL != "" {
+		return entry.WebpageURL
+	}
+	return entry.URL
+}
+
+// UploadedAt The upload time, if the site reports it.
+func (entry PlaylistEntry) UploadedAt() *time.Time {
+	return uploadTime(entry.Timestamp, entry.UploadDate)
+}
+
+// UploadedAt The upload time, if the site reports it.
+func (info VideoInfo) UploadedAt() *time.Time {
+	return uploadTime(info.Timestamp, info.UploadDate)
+}
+
+func uploadTime(timestamp int64, uploadDate string) *time.Time {
+	if timestamp > 0 {
+		uploaded := time.Unix(timestamp, 0)
+		return &uploaded
+	}
+	if uploaded, err := time.Parse("20060102", uploadDate); err == nil {
+		return &uploaded
+	}
+	return nil
+}
+
+// ListPlaylist Enumerates the videos of a playlist or uploads page in the order of the site, without downloading them.
+func ListPlaylist(ctx context.Context, request Request) ([]PlaylistEntry, error) {
+	args := append([]string{"--flat-playlist", "--no-warnings", "--dump-single-json"}, ytDlpOptions(request)...)
+	cmd := exec.CommandContext(ctx, "yt-dlp", append(args, request.URL)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("yt-dlp command timed out for URL %s", request.URL)
+		}
+		return nil, fmt.Errorf("yt-dlp failed for URL %s: %v\nOutput: %s", request.URL, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parsePlaylist(stdout.Bytes())
+}
+
+// parsePlaylist Reads the entries of a flat playlist. Nested playlists, i.e. the tabs of a channel page, are skipped.
+func parsePlaylist(data []byte) ([]PlaylistEntry, error) {
+	var playlist ytDlpPlaylist
+	if err := json.Unmarshal(data, &playlist); err != nil {
+		return nil, fmt.Errorf("error parsing yt-dlp output: %w", err)
+	}
+
+	entries := make([]PlaylistEntry, 0, len(playlist.Entries))
+	for _, entry := range playlist.Entries {
+		if entry.ID == "" || entry.Type == "playlist" {
+			continue
+		}
+		if entry.Extractor == "" {
+			entry.Extractor = playlist.Extractor
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// downloadArgs yt-dlp writes the file with the extension of the output, the metadata is printed to stdout
+// and the progress to stderr.
+func downloadArgs(request Request, output string) []string {
+	format := request.Format
+	if format == "" {
+		format = defaultDownloadFormat
+	}
+
+	// yt-dlp names the file after the extension of the downloaded format, which is remuxed afterwards.
+	extension := filepath.Ext(output)
+	template := strings.TrimSuffix(output, extension) + ".%(ext)s"
+	extension = strings.TrimPrefix(extension, ".")
+
+	args := []string{
+		"--no-warnings",
+		"--no-playlist",
+		"--newline",
+		"--progress",
+		"--progress-template", "download:" + progressPrefix + "%(progress.downloaded_bytes)s %(progress.total_bytes,progress.total_bytes_estimate)s",
+		"-f", format,
+		"--merge-output-format", extension,
+		"--remux-video", extension,
+		"-o", template,
+	}
+	args = append(args, ytDlpOptions(request)...)
+
+	return append(args, "--dump-json", "--no-simulate", request.URL)
+}
+
+// parseProgress Reads a progress line of yt-dlp, the total is 0 if it is not known.
+func parseProgress(line string) (downloaded, total int64, ok bool) {
+	values, found := strings.CutPrefix(line, progressPrefix)
+	if !found {
+		return 0, 0, false
+	}
+
+	fields := strings.Fields(values)
+	if len(fields) != 2 {
+		return 0, 0, false
+	}
+	downloadedBytes, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	// The estimate is a float and both are "NA" if unknown.
+	totalBytes, _ := strconv.ParseFloat(fields[1], 64)
+
+	return int64(downloadedBytes), int64(totalBytes), true
+}
+
+// Download Downloads the video of the request into the output file, i.e. "/recordings/channel/video.mp4".
+// Separate video and audio streams are merged into the container of the output.
+func Download(ctx context.Context, request Request, output string, onProgress func(downloaded, total int64)) (*VideoInfo, error) {
+	cmd := exec.CommandContext(ctx, "yt-dlp", downloadArgs(request, output)...)
+
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+
+	var lastLines []string
+	scanner := bufio.NewScanner(stderr)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if downloaded, total, ok := parseProgress(line); ok {
+			if onProgress != nil {
+				onProgress(downloaded, total)
+			}
+			continue
+		}
+		lastLines = append(lastLines, line)
+		if len(lastLines) > 10 {
+			lastLines = lastLines[1:]
+		}
+	}
+
+	if err := cmd.Wait(); err != nil {
+		return nil, fmt.Errorf("yt-dlp failed for URL %s: %v\nOutput: %s", request.URL, err, strings.Join(lastLines, "\n"))
+	}
+
+	var info VideoInfo
+	if err := json.Unmarshal(stdout.Bytes(), &info); err != nil {
+		return nil, fmt.Errorf("error parsing yt-dlp output: %w", err)
+	}
+
+	return &info, nil
+}
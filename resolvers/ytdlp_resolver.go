@@ -0,0 +1,146 @@
+package resolvers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"sort"
+	"strings"
+)
+
+const defaultYtDlpFormat = "best"
+
+// YtDlpResolver Queries the stream information with yt-dlp, which supports most streaming sites.
+type YtDlpResolver struct{}
+
+type ytDlpFormat struct {
+	URL         string            `json:"url"`
+	HTTPHeaders map[string]string `json:"http_headers"`
+	Cookies     string            `json:"cookies"`
+	Height      uint              `json:"height"`
+}
+
+type ytDlpInfo struct {
+	ytDlpFormat
+	ID               string        `json:"id"`
+	Title            string        `json:"title"`
+	Description      string        `json:"description"`
+	Categories       []string      `json:"categories"`
+	Uploader         string        `json:"uploader"`
+	Channel          string        `json:"channel"`
+	Thumbnail        string        `json:"thumbnail"`
+	IsLive           *bool         `json:"is_live"`
+	LiveStatus       string        `json:"live_status"`
+	RequestedFormats []ytDlpFormat `json:"requested_formats"`
+}
+
+func (r *YtDlpResolver) Resolve(ctx context.Context, request Request) (*Result, error) {
+	cmd := exec.CommandContext(ctx, "yt-dlp", ytDlpArgs(request)...)
+
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	if err := cmd.Run(); err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return nil, fmt.Errorf("yt-dlp command timed out for URL %s", request.URL)
+		}
+		return nil, fmt.Errorf("yt-dlp failed for URL %s: %v\nOutput: %s", request.URL, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return parseYtDlpInfo(stdout.Bytes())
+}
+
+func ytDlpArgs(request Request) []string {
+	format := request.Format
+	if format == "" {
+		format = defaultYtDlpFormat
+	}
+
+	args := []string{
+		"--force-ipv4",
+		"--no-warnings",
+		"--no-playlist",
+		"--youtube-skip-dash-manifest",
+		"-f", format,
+	}
+	args = append(args, ytDlpOptions(request)...)
+
+	return append(args, "--dump-single-json", request.URL)
+}
+
+// ytDlpOptions Applies the capture options of the channel to yt-dlp.
+func ytDlpOptions(request Request) []string {
+	var args []string
+
+	if request.MaxResolution > 0 {
+		// Sorting prefers formats up to the height, the format selector still decides.
+		args = append(args, "-S", fmt.Sprintf("res:%d", request.MaxResolution))
+	}
+	if request.Proxy != "" {
+		args = append(args, "--proxy", request.Proxy)
+	}
+	if request.Cookies != "" {
+		args = append(args, "--cookies", request.Cookies)
+	}
+
+	keys := make([]string, 0, len(request.Headers))
+	for key := range request.Headers {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		args = append(args, "--add-header", fmt.Sprintf("%s:%s", key, request.Headers[key]))
+	}
+
+	return args
+}
+
+// parseYtDlpInfo Reads the JSON document printed by yt-dlp.
+// If the format selector picked separate streams, i.e. "bv+ba", the first one is used.
+func parseYtDlpInfo(data []byte) (*Result, error) {
+	var info ytDlpInfo
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("error parsing yt-dlp output: %w", err)
+	}
+
+	format := info.ytDlpFormat
+	if format.URL == "" && len(info.RequestedFormats) > 0 {
+		format = info.RequestedFormats[0]
+	}
+	if format.URL == "" {
+		return nil, errors.New("yt-dlp returned no stream url")
+	}
+
+	isLive := info.LiveStatus == "is_live"
+	if info.IsLive != nil {
+		isLive = *info.IsLive
+	}
+
+	// Most live extractors report the streamer as uploader, some only as channel.
+	uploader := info.Uploader
+	if uploader == "" {
+		uploader = info.Channel
+	}
+	var category string
+	if len(info.Categories) > 0 {
+		category = info.Categories[0]
+	}
+
+	return &Result{
+		URL:          format.URL,
+		Headers:      format.HTTPHeaders,
+		Title:        info.Title,
+		IsLive:       isLive,
+		Cookies:      format.Cookies,
+		Description:  info.Description,
+		Category:     category,
+		Uploader:     uploader,
+		ThumbnailURL: info.Thumbnail,
+		OriginalID:   info.ID,
+		Height:       format.Height,
+	}, nil
+}
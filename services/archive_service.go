@@ -0,0 +1,118 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/resolvers"
+)
+
+// archiveExtension Videos of archives are merged or remuxed into this container by yt-dlp.
+const archiveExtension = ".mp4"
+
+// checkArchive Lists the playlist of the channel and enqueues the download of each video which is not known yet.
+// The items of the channel are the download archive, deleted recordings are not downloaded again.
+// The first check only downloads the latest videos up to the backfill limit.
+// Returns the number of new videos.
+func checkArchive(channel *database.Channel) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), playlistTimeout)
+	defer cancel()
+
+	entries, err := resolvers.ListPlaylist(ctx, resolverRequest(channel))
+	if err != nil {
+		return 0, err
+	}
+
+	known, err := channel.ChannelID.CountChannelItems()
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	// Uploads pages list the latest video first, the older ones are downloaded first.
+	for i := len(entries) - 1; i >= 0; i-- {
+		entry := entries[i]
+		item := &database.ChannelItem{
+			ChannelID:   channel.ChannelID,
+			GUID:        entry.ArchiveID(),
+			Title:       strings.TrimSpace(entry.Title),
+			PublishedAt: entry.UploadedAt(),
+			URL:         entry.Link(),
+		}
+
+		isNew, errAdd := database.AddChannelItem(item)
+		if errAdd != nil {
+			return added, errAdd
+		}
+		if !isNew || !isBackfilled(channel, known == 0, i) {
+			continue
+		}
+
+		if err := enqueueItemDownload(item, archiveExtension); err != nil {
+			return added, err
+		}
+
+		log.Infof("[Archive] New video '%s' of %s", item.Title, channel.ChannelName)
+		added++
+	}
+
+	return added, nil
+}
+
+// pollArchive Checks the playlist and schedules the next check, archives are synced in a fixed interval.
+func pollArchive(channel *database.Channel) {
+	added, err := checkArchive(channel)
+
+	logResolverError(channel.ChannelID, err)
+	if err != nil {
+		log.Warnf("[Archive] Error listing playlist of %s: %v", channel.ChannelName, err)
+		recordPoll(channel.ChannelID, false, time.Now())
+		return
+	}
+
+	log.Debugf("[Archive] %d new videos of %s", added, channel.ChannelName)
+	schedulePoll(channel.ChannelID, archivePollInterval, time.Now())
+}
+
+// downloadVideo Downloads a video with yt-dlp into the file of its recording, i.e. of an archive or a fetched page.
+// The title and upload date of the video metadata replace the ones of the playlist.
+func downloadVideo(ctx context.Context, channel *database.Channel, recording *database.Recording, item *database.ChannelItem, target string, onProgress func(written, total int64)) error {
+	request := resolverRequest(channel)
+	request.URL = target
+
+	var reportedAt time.Time
+	info, err := resolvers.Download(ctx, request, recording.AbsoluteChannelFilepath(), func(downloaded, total int64) {
+		if onProgress != nil && time.Since(reportedAt) >= progressEventInterval {
+			reportedAt = time.Now()
+			onProgress(downloaded, total)
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	title := strings.TrimSpace(info.Title)
+	if title != "" && title != recording.Title {
+		if errTitle := recording.UpdateTitle(title); errTitle != nil {
+			log.Errorf("[Job] %s", errTitle)
+		}
+	}
+
+	if item != nil {
+		publishedAt := info.UploadedAt()
+		if publishedAt == nil {
+			publishedAt = item.PublishedAt
+		}
+		if title == "" {
+			title = item.Title
+		}
+		if errItem := item.UpdateMetadata(title, strings.TrimSpace(info.Description), publishedAt); errItem != nil {
+			log.Errorf("[Job] %s", errItem)
+		}
+	}
+
+	return nil
+}
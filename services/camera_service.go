@@ -0,0 +1,95 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+// dayLayout Format of the days of the camera index.
+const dayLayout = "2006-01-02"
+
+// CameraHour The segments which started within one hour of the day.
+type CameraHour struct {
+	Hour       int                   `json:"hour" extensions:"!x-nullable"`
+	Duration   float64               `json:"duration" extensions:"!x-nullable"` // Seconds
+	Size       uint64                `json:"size" extensions:"!x-nullable"`
+	Recordings []*database.Recording `json:"recordings" extensions:"!x-nullable"`
+}
+
+// CameraDay The hours of a day which have footage, in order.
+type CameraDay struct {
+	Day   string       `json:"day" extensions:"!x-nullable"` // YYYY-MM-DD
+	Hours []CameraHour `json:"hours" extensions:"!x-nullable"`
+}
+
+// cameraSegment The segment length of a camera, the segment duration of the channel or 10 minutes.
+func cameraSegment(channel *database.Channel) time.Duration {
+	if channel.SegmentDuration > 0 {
+		return time.Duration(channel.SegmentDuration) * time.Minute
+	}
+	return cameraSegmentMinutes * time.Minute
+}
+
+// recordingStart The capture start of a segment, recordings which have not been captured only have a creation date.
+func recordingStart(recording *database.Recording) time.Time {
+	if recording.StartedAt != nil {
+		return *recording.StartedAt
+	}
+	return recording.CreatedAt
+}
+
+// indexRecordings Groups the recordings by the day and hour of their start in the location.
+// The recordings are expected in capture order, days and hours are ordered likewise.
+func indexRecordings(recordings []*database.Recording, location *time.Location) []CameraDay {
+	var days []CameraDay
+
+	for _, recording := range recordings {
+		start := recordingStart(recording).In(location)
+		day := start.Format(dayLayout)
+
+		if len(days) == 0 || days[len(days)-1].Day != day {
+			days = append(days, CameraDay{Day: day})
+		}
+		current := &days[len(days)-1]
+
+		if len(current.Hours) == 0 || current.Hours[len(current.Hours)-1].Hour != start.Hour() {
+			current.Hours = append(current.Hours, CameraHour{Hour: start.Hour()})
+		}
+		hour := &current.Hours[len(current.Hours)-1]
+
+		hour.Recordings = append(hour.Recordings, recording)
+		hour.Duration += recording.Duration
+		hour.Size += recording.Size
+	}
+
+	return days
+}
+
+// CameraIndex The footage of the channel by day and hour in local time, optionally only of one day (YYYY-MM-DD).
+func CameraIndex(id database.ChannelID, day string) ([]CameraDay, error) {
+	if day != "" {
+		if _, err := time.ParseInLocation(dayLayout, day, time.Local); err != nil {
+			return nil, fmt.Errorf("invalid day '%s', expected YYYY-MM-DD", day)
+		}
+	}
+
+	recordings, err := id.FindRecordings(database.RecordingStatusReady)
+	if err != nil {
+		return nil, err
+	}
+
+	days := indexRecordings(recordings, time.Local)
+	if day == "" {
+		return days, nil
+	}
+
+	for _, indexed := range days {
+		if indexed.Day == day {
+			return []CameraDay{indexed}, nil
+		}
+	}
+
+	return []CameraDay{}, nil
+}
@@ -0,0 +1,55 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestIndexRecordings(t *testing.T) {
+	at := func(value string) time.Time {
+		parsed, err := time.ParseInLocation("2006-01-02 15:04", value, time.UTC)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return parsed
+	}
+	started := at("2024-03-01 23:50")
+
+	recordings := []*database.Recording{
+		{RecordingID: 1, CreatedAt: at("2024-03-01 22:40"), StartedAt: &started, Duration: 600, Size: 10},
+		{RecordingID: 2, CreatedAt: at("2024-03-02 00:00"), Duration: 600, Size: 20},
+		{RecordingID: 3, CreatedAt: at("2024-03-02 00:10"), Duration: 300, Size: 30},
+		{RecordingID: 4, CreatedAt: at("2024-03-02 07:00"), Duration: 600, Size: 40},
+	}
+
+	days := indexRecordings(recordings, time.UTC)
+	if len(days) != 2 || days[0].Day != "2024-03-01" || days[1].Day != "2024-03-02" {
+		t.Fatalf("days are %+v", days)
+	}
+
+	if hours := days[0].Hours; len(hours) != 1 || hours[0].Hour != 23 || len(hours[0].Recordings) != 1 {
+		t.Errorf("hours of the first day are %+v", hours)
+	}
+
+	hours := days[1].Hours
+	if len(hours) != 2 || hours[0].Hour != 0 || hours[1].Hour != 7 {
+		t.Fatalf("hours of the second day are %+v", hours)
+	}
+	if ids := recordingIDs(hours[0].Recordings); !equalIDs(ids, 2, 3) {
+		t.Errorf("recordings of hour 0 are %v", ids)
+	}
+	if hours[0].Duration != 900 || hours[0].Size != 50 {
+		t.Errorf("hour 0 has duration %f and size %d", hours[0].Duration, hours[0].Size)
+	}
+}
+
+func TestCameraSegment(t *testing.T) {
+	if segment := cameraSegment(&database.Channel{}); segment != 10*time.Minute {
+		t.Errorf("default segment is %s", segment)
+	}
+	if segment := cameraSegment(&database.Channel{SegmentDuration: 15}); segment != 15*time.Minute {
+		t.Errorf("segment is %s", segment)
+	}
+}
@@ -0,0 +1,229 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/resolvers"
+)
+
+// pipeWriter Passes the data to the ffmpeg process of the current part, the part is replaced on rollovers.
+type pipeWriter struct {
+	lock sync.Mutex
+	part *capturePart
+}
+
+func (writer *pipeWriter) Write(p []byte) (int, error) {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	return writer.part.stdin.Write(p)
+}
+
+// swap Directs the data to the next part and closes the input of the previous part, so that its ffmpeg process exits.
+func (writer *pipeWriter) swap(next *capturePart) *capturePart {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+
+	previous := writer.part
+	writer.part = next
+	if err := previous.stdin.Close(); err != nil {
+		log.Errorf("[Capture] Error closing input of '%s': %v", previous.outputPath, err)
+	}
+
+	return previous
+}
+
+func (writer *pipeWriter) close() {
+	writer.lock.Lock()
+	defer writer.lock.Unlock()
+	if err := writer.part.stdin.Close(); err != nil {
+		log.Errorf("[Capture] Error closing input of '%s': %v", writer.part.outputPath, err)
+	}
+}
+
+// rolloverPipePart Starts the next part of the session. Unlike rolloverCapturePart the stream is not requested
+// again, the data is passed on to the next part after the swap.
+func rolloverPipePart(channel *database.Channel, stream *resolvers.Result, writer *pipeWriter, sessionID database.SessionID) (*capturePart, error) {
+	next, err := newCapturePart(channel, stream, 0, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := next.start(); err != nil {
+		discardCapturePart(next)
+		return nil, err
+	}
+
+	activeRecLock.Lock()
+	recInfo[channel.ChannelID] = next.recording
+	streams[channel.ChannelID] = next.cmd
+	parts[channel.ChannelID] = next
+	activeRecLock.Unlock()
+
+	previous := writer.swap(next)
+
+	// The previous part exits on its own, once it has written the remaining data.
+	go func() {
+		if errFinish := finishCapturePart(channel, previous, <-previous.done); errFinish != nil {
+			log.Errorf("[Capture] Error finishing part '%s': %v", previous.outputPath, errFinish)
+		}
+	}()
+
+	// The capture might have been terminated while the maps still referenced the previous part.
+	if IsTerminating(channel.ChannelID) {
+		next.stop()
+	}
+
+	return next, nil
+}
+
+// capturePipe Captures the data of the source like CaptureChannel captures a URL, ffmpeg reads it from stdin.
+// This is used for streams which can only be read once, see CaptureRadio and the ingest listeners.
+// The source is read until it ends or the capture is stopped, which cancels the context.
+// Titles are written to the cue sheet of the current part, the channel for streams without metadata is nil.
+func capturePipe(ctx context.Context, cancel context.CancelFunc, channel *database.Channel, stream *resolvers.Result, source io.Reader, skip uint, titles <-chan string) error {
+	id := channel.ChannelID
+
+	activeRecLock.Lock()
+	if _, ok := streams[id]; ok {
+		activeRecLock.Unlock()
+		log.Debugf("capturePipe: Stream %d already in map, capture not starting again.", id)
+		return nil
+	}
+
+	if errMkDir := channel.ChannelName.MkDir(); errMkDir != nil && !os.IsExist(errMkDir) {
+		activeRecLock.Unlock()
+		return fmt.Errorf("capturePipe: failed to create directory for %s: %w", channel.ChannelName, errMkDir)
+	}
+
+	session := resumeSession(id)
+	sessionID := session.id
+
+	part, err := newCapturePart(channel, stream, skip, sessionID)
+	if err != nil {
+		activeRecLock.Unlock()
+		return fmt.Errorf("capturePipe: %w", err)
+	}
+
+	recInfo[id] = part.recording
+	streams[id] = part.cmd
+	parts[id] = part
+	activeRecLock.Unlock()
+
+	log.Infof("[Capture] Capturing %s to %s (session: %s)", channel.ChannelName, part.outputPath, sessionID)
+
+	if err := part.start(); err != nil {
+		setRecordingStatus(part.recording, database.RecordingStatusFailed)
+		endSession(channel, session)
+		return fmt.Errorf("ffmpeg cmd.Start failed for %s: %w", channel.ChannelName, err)
+	}
+
+	copied := make(chan error, 1)
+	writer := &pipeWriter{part: part}
+
+	go func() {
+		_, errCopy := io.Copy(writer, source)
+		copied <- errCopy
+	}()
+
+	ticker := time.NewTicker(segmentCheckInterval)
+	defer ticker.Stop()
+
+	// Tracks are only split by the titles of the stream.
+	splitTracks := channel.SplitTracks && titles != nil
+
+	var tracks []icyTrack
+	stalled := false
+	var backoff rolloverBackoff
+
+	rollover := func() bool {
+		// The input of the current part has already been closed.
+		if copied == nil || !backoff.ready(time.Now()) {
+			return false
+		}
+		next, errRollover := rolloverPipePart(channel, stream, writer, sessionID)
+		if errRollover != nil {
+			log.Errorf("[Capture] Rollover failed for %s, continuing current part, next attempt in %s: %v", channel.ChannelName, backoff.failed(time.Now()), errRollover)
+			return false
+		}
+		backoff.reset()
+		part = next
+		return true
+	}
+
+	for {
+		select {
+		case waitErr := <-part.done:
+			// Stops reading the source.
+			cancel()
+			errFinish := finishCapturePart(channel, part, waitErr)
+			endSession(channel, session)
+			if stalled {
+				return errors.Join(errCaptureStalled, errFinish)
+			}
+			return errFinish
+
+		case errCopy := <-copied:
+			// The source ended or the part exited, ffmpeg exits once its input is closed.
+			if errCopy != nil && ctx.Err() == nil {
+				log.Warnf("[Capture] Stream of %s ended: %v", channel.ChannelName, errCopy)
+			}
+			writer.close()
+			copied = nil
+
+		case title := <-titles:
+			if len(tracks) > 0 && tracks[len(tracks)-1].Title == title {
+				continue
+			}
+			log.Infof("[Capture] %s is playing '%s'", channel.ChannelName, title)
+
+			if splitTracks && len(tracks) > 0 && !stalled && !IsTerminating(id) && rollover() {
+				tracks = nil
+			}
+
+			tracks = append(tracks, icyTrack{Offset: time.Since(part.startedAt), Title: title})
+			if errChange := database.AddRecordingTitleChange(part.recording.RecordingID, title, "", time.Now()); errChange != nil {
+				log.Errorf("[Capture] Error adding title change of '%s': %v", part.outputPath, errChange)
+			}
+			if splitTracks && len(tracks) == 1 {
+				if errTitle := part.recording.UpdateTitle(title); errTitle != nil {
+					log.Errorf("[Capture] %v", errTitle)
+				}
+			}
+			if errCue := writeCueSheet(channel, part.recording, tracks); errCue != nil {
+				log.Errorf("[Capture] Error writing cue sheet of '%s': %v", part.outputPath, errCue)
+			}
+
+		case <-ticker.C:
+			if errProgress := part.recording.UpdateProgress(uint64(part.size())); errProgress != nil {
+				log.Errorf("[Capture] Error updating progress of '%s': %v", part.outputPath, errProgress)
+			}
+
+			if stalled || IsTerminating(id) {
+				continue
+			}
+
+			if part.isStalled(channel) {
+				stalled = true
+				reportStall(channel, part, sessionID)
+				cancel()
+				part.stop()
+				continue
+			}
+
+			// Without track splitting, the segment policy applies and the current track continues in the next part.
+			if !splitTracks && part.exceedsSegmentPolicy(channel) && rollover() && len(tracks) > 0 {
+				tracks = []icyTrack{{Title: tracks[len(tracks)-1].Title}}
+				if errCue := writeCueSheet(channel, part.recording, tracks); errCue != nil {
+					log.Errorf("[Capture] Error writing cue sheet of '%s': %v", part.outputPath, errCue)
+				}
+			}
+		}
+	}
+}
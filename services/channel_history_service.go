@@ -0,0 +1,154 @@
+package services
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+)
+
+// WeekdayStart Median time of day at which the channel went online on this weekday.
+type WeekdayStart struct {
+	Weekday  time.Weekday `json:"weekday" extensions:"!x-nullable"`
+	Start    string       `json:"start" extensions:"!x-nullable"` // 15:04, server time
+	Sessions int          `json:"sessions" extensions:"!x-nullable"`
+}
+
+type ChannelStats struct {
+	Sessions              int            `json:"sessions" extensions:"!x-nullable"`
+	LiveHours             float64        `json:"liveHours" extensions:"!x-nullable"`
+	AverageSessionMinutes float64        `json:"averageSessionMinutes" extensions:"!x-nullable"`
+	LastOnlineAt          *time.Time     `json:"lastOnlineAt"`
+	TypicalStarts         []WeekdayStart `json:"typicalStarts" extensions:"!x-nullable"`
+}
+
+// ChannelHistory Timeline of a channel with statistics derived from it.
+type ChannelHistory struct {
+	Sessions []*database.ChannelSession      `json:"sessions" extensions:"!x-nullable"`
+	Events   []*database.ChannelSessionEvent `json:"events" extensions:"!x-nullable"`
+	Stats    ChannelStats                    `json:"stats" extensions:"!x-nullable"`
+}
+
+var (
+	// The resolver of an offline channel usually fails with the same error on each poll, which is only logged once.
+	resolverErrors     = make(map[database.ChannelID]string)
+	resolverErrorsLock sync.Mutex
+)
+
+func GetChannelHistory(id database.ChannelID, since time.Time) (*ChannelHistory, error) {
+	sessions, err := id.FindChannelSessions(since)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := id.FindChannelSessionEvents(since)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ChannelHistory{
+		Sessions: sessions,
+		Events:   events,
+		Stats:    channelStats(sessions, time.Now()),
+	}, nil
+}
+
+// channelStats Sessions which are still open count until now.
+func channelStats(sessions []*database.ChannelSession, now time.Time) ChannelStats {
+	stats := ChannelStats{Sessions: len(sessions), TypicalStarts: []WeekdayStart{}}
+	if len(sessions) == 0 {
+		return stats
+	}
+
+	var total time.Duration
+	startMinutes := make(map[time.Weekday][]int)
+
+	for _, session := range sessions {
+		endedAt := now
+		if session.EndedAt != nil {
+			endedAt = *session.EndedAt
+		}
+		total += endedAt.Sub(session.StartedAt)
+
+		if stats.LastOnlineAt == nil || endedAt.After(*stats.LastOnlineAt) {
+			lastOnlineAt := endedAt
+			stats.LastOnlineAt = &lastOnlineAt
+		}
+
+		local := session.StartedAt.In(time.Local)
+		startMinutes[local.Weekday()] = append(startMinutes[local.Weekday()], local.Hour()*60+local.Minute())
+	}
+
+	stats.LiveHours = total.Hours()
+	stats.AverageSessionMinutes = total.Minutes() / float64(len(sessions))
+
+	for weekday := time.Sunday; weekday <= time.Saturday; weekday++ {
+		minutes, ok := startMinutes[weekday]
+		if !ok {
+			continue
+		}
+		sort.Ints(minutes)
+		median := minutes[len(minutes)/2]
+		stats.TypicalStarts = append(stats.TypicalStarts, WeekdayStart{
+			Weekday:  weekday,
+			Start:    time.Date(0, 1, 1, median/60, median%60, 0, 0, time.UTC).Format(scheduleTimeLayout),
+			Sessions: len(minutes),
+		})
+	}
+
+	return stats
+}
+
+func addChannelSessionEvent(id database.ChannelID, sessionID *database.SessionID, event database.ChannelSessionEventType, message string) {
+	if err := database.AddChannelSessionEvent(id, sessionID, event, message); err != nil {
+		log.Errorf("[History] Error logging %s event of channel %d: %s", event, id, err)
+	}
+}
+
+// logResolverError Logs the error of the resolver, unless it is the same as the previous one. nil resets the error.
+func logResolverError(id database.ChannelID, err error) {
+	resolverErrorsLock.Lock()
+	defer resolverErrorsLock.Unlock()
+
+	if err == nil {
+		delete(resolverErrors, id)
+		return
+	}
+	if resolverErrors[id] == err.Error() {
+		return
+	}
+	resolverErrors[id] = err.Error()
+
+	addChannelSessionEvent(id, nil, database.ChannelSessionEventError, err.Error())
+}
+
+// closeInterruptedChannelSessions Sessions which were open when the server stopped end with their last written data.
+func closeInterruptedChannelSessions() error {
+	sessions, err := database.FindOpenChannelSessions()
+	if err != nil {
+		return err
+	}
+
+	for _, session := range sessions {
+		endedAt := session.StartedAt
+		recordings, errRecordings := session.SessionID.FindRecordings(database.RecordingStatusRecording, database.RecordingStatusFinalizing, database.RecordingStatusReady)
+		if errRecordings != nil {
+			log.Errorf("[History] Error querying recordings of session %s: %s", session.SessionID, errRecordings)
+		}
+		for _, recording := range recordings {
+			if recording.LastProgressAt != nil && recording.LastProgressAt.After(endedAt) {
+				endedAt = *recording.LastProgressAt
+			}
+		}
+
+		if err := session.SessionID.CloseChannelSession(endedAt); err != nil {
+			log.Errorf("[History] Error closing session %s: %s", session.SessionID, err)
+			continue
+		}
+		addChannelSessionEvent(session.ChannelID, &session.SessionID, database.ChannelSessionEventOffline, "server stopped")
+	}
+
+	return nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestChannelStats(t *testing.T) {
+	// Mondays in server time.
+	monday := time.Date(2024, 1, 1, 0, 0, 0, 0, time.Local)
+	at := func(days, hour, minute int) time.Time {
+		return monday.AddDate(0, 0, days).Add(time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute)
+	}
+	ended := func(t time.Time) *time.Time { return &t }
+
+	now := at(14, 21, 0)
+	sessions := []*database.ChannelSession{
+		{StartedAt: at(0, 20, 0), EndedAt: ended(at(0, 22, 0))},
+		{StartedAt: at(7, 20, 30), EndedAt: ended(at(7, 21, 30))},
+		{StartedAt: at(9, 18, 0), EndedAt: ended(at(9, 19, 0))},
+		{StartedAt: at(14, 20, 0)}, // Still online
+	}
+
+	stats := channelStats(sessions, now)
+
+	if stats.Sessions != 4 {
+		t.Errorf("Sessions is %d but should be 4", stats.Sessions)
+	}
+	if stats.LiveHours != 5 {
+		t.Errorf("LiveHours is %f but should be 5", stats.LiveHours)
+	}
+	if stats.AverageSessionMinutes != 75 {
+		t.Errorf("AverageSessionMinutes is %f but should be 75", stats.AverageSessionMinutes)
+	}
+	if stats.LastOnlineAt == nil || !stats.LastOnlineAt.Equal(now) {
+		t.Errorf("LastOnlineAt is %v but should be %s", stats.LastOnlineAt, now)
+	}
+
+	expected := []WeekdayStart{
+		{Weekday: time.Monday, Start: "20:00", Sessions: 3},
+		{Weekday: time.Wednesday, Start: "18:00", Sessions: 1},
+	}
+	if len(stats.TypicalStarts) != len(expected) {
+		t.Fatalf("TypicalStarts is %v but should be %v", stats.TypicalStarts, expected)
+	}
+	for i := range expected {
+		if stats.TypicalStarts[i] != expected[i] {
+			t.Errorf("TypicalStarts[%d] is %v but should be %v", i, stats.TypicalStarts[i], expected[i])
+		}
+	}
+}
+
+func TestChannelStatsEmpty(t *testing.T) {
+	stats := channelStats(nil, time.Now())
+	if stats.Sessions != 0 || stats.LastOnlineAt != nil || len(stats.TypicalStarts) != 0 {
+		t.Errorf("channelStats(nil) is %+v but should be empty", stats)
+	}
+}
@@ -17,20 +17,17 @@ type ChannelInfo struct {
 	IsTerminating bool    `json:"isTerminating" extensions:"!x-nullable"`
 	Preview       string  `json:"preview" extensions:"!x-nullable"`
 	MinRecording  float64 `json:"minRecording" extensions:"!x-nullable"`
+
+	// The part which is currently being captured, as persisted in the database.
+	Recording *database.Recording `json:"recording"`
+	// Offline channels are queried less often, nil if the channel is checked in the next round.
+	NextCheckAt *time.Time `json:"nextCheckAt"`
 }
 
 // CreateChannel Persistent channel generation.
-func CreateChannel(name, displayName string, skipStart, minDuration uint, url string, fav bool, tags *database.Tags, isPaused bool) (*ChannelInfo, error) {
-	channel := database.Channel{
-		ChannelName: database.ChannelName(name),
-		DisplayName: displayName,
-		SkipStart:   skipStart,
-		MinDuration: minDuration,
-		CreatedAt:   time.Now(),
-		URL:         url,
-		Fav:         fav,
-		Tags:        tags,
-		IsPaused:    isPaused}
+func CreateChannel(channel database.Channel) (*ChannelInfo, error) {
+	channel.CreatedAt = time.Now()
+	channel.Deleted = false
 
 	newChannel, err := database.CreateChannelDetail(channel)
 
@@ -54,6 +51,26 @@ func CreateChannel(name, displayName string, skipStart, minDuration uint, url st
 	return info, nil
 }
 
+// CheckIngestPort Two ingest channels can't listen on the same port with the same protocol.
+func CheckIngestPort(channel *database.Channel) error {
+	if channel.Type != database.ChannelTypeIngest {
+		return nil
+	}
+
+	channels, err := database.ChannelListNotDeleted()
+	if err != nil {
+		return err
+	}
+
+	for _, other := range channels {
+		if other.ChannelID != channel.ChannelID && other.Type == database.ChannelTypeIngest && other.IngestPort == channel.IngestPort && other.IngestProtocol.String() == channel.IngestProtocol.String() {
+			return fmt.Errorf("ingest port %d is already used by channel %s", channel.IngestPort, other.ChannelName)
+		}
+	}
+
+	return nil
+}
+
 // GetChannels Adds additional streaming and recording information to the channel data in the database.
 func GetChannels() ([]ChannelInfo, error) {
 	channels, err := database.ChannelListNotDeleted()
@@ -61,9 +78,22 @@ func GetChannels() ([]ChannelInfo, error) {
 		return nil, err
 	}
 
+	active, err := database.RecordingsListByStatus(database.RecordingStatusRecording)
+	if err != nil {
+		return nil, err
+	}
+
+	activeByChannel := make(map[database.ChannelID]*database.Recording, len(active))
+	for _, recording := range active {
+		// Ordered by creation, so the latest part wins.
+		activeByChannel[recording.ChannelID] = recording
+	}
+
 	response := make([]ChannelInfo, len(channels))
 
 	for index, channel := range channels {
+		recording := activeByChannel[channel.ChannelID]
+
 		// Add to each channel current system information
 		response[index] = ChannelInfo{
 			Channel:       *channel,
@@ -71,7 +101,9 @@ func GetChannels() ([]ChannelInfo, error) {
 			IsOnline:      IsOnline(channel.ChannelID),
 			IsTerminating: IsTerminating(channel.ChannelID),
 			IsRecording:   IsRecordingStream(channel.ChannelID),
-			MinRecording:  GetRecordingMinutes(channel.ChannelID),
+			MinRecording:  recordingMinutes(recording),
+			Recording:     recording,
+			NextCheckAt:   NextCheckAt(channel.ChannelID),
 		}
 	}
 
@@ -86,16 +118,31 @@ func GetChannel(id uint) (*ChannelInfo, error) {
 		return nil, fmt.Errorf("channel not found: %w", err)
 	}
 
+	recording, err := channel.ChannelID.FindActiveRecording()
+	if err != nil {
+		return nil, err
+	}
+
 	return &ChannelInfo{
 		Channel:       *channel,
 		IsOnline:      IsOnline(channel.ChannelID),
 		IsTerminating: IsTerminating(channel.ChannelID),
 		IsRecording:   IsRecordingStream(channel.ChannelID),
-		MinRecording:  GetRecordingMinutes(channel.ChannelID),
+		MinRecording:  recordingMinutes(recording),
 		Preview:       channel.ChannelName.PreviewPath(),
+		Recording:     recording,
+		NextCheckAt:   NextCheckAt(channel.ChannelID),
 	}, nil
 }
 
+// recordingMinutes Minutes since the capture of the recording started, 0 if nothing is captured.
+func recordingMinutes(recording *database.Recording) float64 {
+	if recording == nil || recording.StartedAt == nil {
+		return 0
+	}
+	return time.Since(*recording.StartedAt).Minutes()
+}
+
 func DeleteChannel(channelID database.ChannelID) error {
 	var err1, err2 error
 	if err := TerminateProcess(channelID); err != nil {
@@ -108,6 +155,7 @@ func DeleteChannel(channelID database.ChannelID) error {
 
 	err := errors.Join(err1, err2)
 	if err == nil {
+		ResetPoll(channelID)
 		log.Infof("Deleted channel %d", channelID)
 	}
 
@@ -0,0 +1,114 @@
+package services
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/conf"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/helpers"
+	"github.com/srad/mediasink/network"
+)
+
+type DiskLevelName string
+
+const (
+	DiskLevelOK       DiskLevelName = "ok"
+	DiskLevelWarning  DiskLevelName = "warning"  // Only recordings are finalized, all other jobs are paused.
+	DiskLevelCritical DiskLevelName = "critical" // No new captures, the running ones are stopped and finalized once their copy fits on the disk.
+)
+
+type DiskStatus struct {
+	Level DiskLevelName     `json:"level" extensions:"!x-nullable"`
+	Disk  *helpers.DiskInfo `json:"disk" extensions:"!x-nullable"`
+}
+
+var (
+	diskLevel       = DiskLevelOK
+	diskLevelLock   sync.RWMutex
+	cancelDiskWatch context.CancelFunc
+)
+
+// DiskLevel The last level of used disk space measured by the watchdog.
+func DiskLevel() DiskLevelName {
+	diskLevelLock.RLock()
+	defer diskLevelLock.RUnlock()
+	return diskLevel
+}
+
+func StartDiskWatchdog() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelDiskWatch = cancel
+	go diskWatchdog(ctx)
+}
+
+func StopDiskWatchdog() {
+	if cancelDiskWatch != nil {
+		cancelDiskWatch()
+	}
+}
+
+func diskWatchdog(ctx context.Context) {
+	log.Infoln("[Disk] Watchdog started.")
+	defer log.Infoln("[Disk] Watchdog stopped.")
+
+	ticker := time.NewTicker(diskCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := checkDisk(); err != nil {
+			log.Errorf("[Disk] Error checking disk space: %s", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func checkDisk() error {
+	info, err := helpers.DiskUsage(conf.Read().DataDisk)
+	if err != nil {
+		return err
+	}
+
+	warning, err := database.GetIntValue(database.DiskWarning)
+	if err != nil {
+		return err
+	}
+	critical, err := database.GetIntValue(database.DiskCritical)
+	if err != nil {
+		return err
+	}
+
+	level := DiskLevelOK
+	switch {
+	case critical > 0 && info.Pcent >= critical:
+		level = DiskLevelCritical
+	case warning > 0 && info.Pcent >= warning:
+		level = DiskLevelWarning
+	}
+
+	diskLevelLock.Lock()
+	previous := diskLevel
+	diskLevel = level
+	diskLevelLock.Unlock()
+
+	if level == previous {
+		return nil
+	}
+
+	log.Warnf("[Disk] Disk usage at %d%%, level changed from %s to %s", info.Pcent, previous, level)
+	network.BroadCastClients(network.SystemDiskEvent, DiskStatus{Level: level, Disk: info})
+
+	if level == DiskLevelCritical {
+		// The captures end regularly, so their recordings are finalized.
+		TerminateAll()
+	}
+
+	return nil
+}
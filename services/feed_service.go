@@ -0,0 +1,554 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/helpers"
+	"github.com/srad/mediasink/network"
+)
+
+// maxFeedSize Upper limit of the feed document, podcast feeds with their whole history can be several megabytes.
+const maxFeedSize = 32 * 1024 * 1024
+
+// feedItem An episode of an RSS or Atom feed which has a media enclosure.
+type feedItem struct {
+	GUID        string
+	Title       string
+	Description string
+	PublishedAt *time.Time
+	URL         string
+	MediaType   string
+}
+
+type rssEnclosure struct {
+	URL  string `xml:"url,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssItem struct {
+	GUID        string         `xml:"guid"`
+	Title       string         `xml:"title"`
+	Description string         `xml:"description"`
+	PubDate     string         `xml:"pubDate"`
+	Enclosure   rssEnclosure   `xml:"enclosure"`
+	Media       []rssEnclosure `xml:"http://search.yahoo.com/mrss/ content"`
+}
+
+type rssFeed struct {
+	Items []rssItem `xml:"channel>item"`
+}
+
+type atomLink struct {
+	Rel  string `xml:"rel,attr"`
+	Href string `xml:"href,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type atomEntry struct {
+	ID        string     `xml:"id"`
+	Title     string     `xml:"title"`
+	Summary   string     `xml:"summary"`
+	Content   string     `xml:"content"`
+	Published string     `xml:"published"`
+	Updated   string     `xml:"updated"`
+	Links     []atomLink `xml:"link"`
+}
+
+type atomFeed struct {
+	Entries []atomEntry `xml:"entry"`
+}
+
+// feedDateLayouts RSS dates are RFC 822 dates, which feeds write in many variants.
+var feedDateLayouts = []string{
+	time.RFC1123Z,
+	time.RFC1123,
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	time.RFC822Z,
+	time.RFC822,
+	time.RFC3339,
+}
+
+// feedExtensions Media types of enclosures, whose URL has no known extension.
+var feedExtensions = map[string]string{
+	"audio/mpeg":  ".mp3",
+	"audio/mp3":   ".mp3",
+	"audio/mp4":   ".m4a",
+	"audio/x-m4a": ".m4a",
+	"audio/aac":   ".m4a",
+	"audio/ogg":   ".opus",
+	"audio/opus":  ".opus",
+	"video/mp4":   ".mp4",
+	"video/x-m4v": ".mp4",
+}
+
+func parseFeedDate(value string) *time.Time {
+	value = strings.TrimSpace(value)
+	for _, layout := range feedDateLayouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return &date
+		}
+	}
+	return nil
+}
+
+// windows1252 The characters of the bytes 0x80-0x9f in Windows-1252, the other bytes match ISO-8859-1.
+var windows1252 = [32]rune{
+	'€', '\u0081', '‚', 'ƒ', '„', '…', '†', '‡', 'ˆ', '‰', 'Š', '‹', 'Œ', '\u008d', 'Ž', '\u008f',
+	'\u0090', '‘', '’', '“', '”', '•', '–', '—', '˜', '™', 'š', '›', 'œ', '\u009d', 'ž', 'Ÿ',
+}
+
+// feedCharsetReader Decodes the single byte encodings which feeds declare besides UTF-8, other encodings are rejected.
+func feedCharsetReader(label string, input io.Reader) (io.Reader, error) {
+	var table *[32]rune
+	switch strings.ToLower(strings.TrimSpace(label)) {
+	case "us-ascii", "ascii", "utf8":
+		return input, nil
+	case "iso-8859-1", "iso8859-1", "latin1", "l1":
+	case "windows-1252", "cp1252":
+		table = &windows1252
+	default:
+		return nil, fmt.Errorf("unsupported feed encoding %q", label)
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded strings.Builder
+	decoded.Grow(len(data))
+	for _, b := range data {
+		if table != nil && b >= 0x80 && b < 0xa0 {
+			decoded.WriteRune(table[b-0x80])
+		} else {
+			decoded.WriteRune(rune(b))
+		}
+	}
+
+	return strings.NewReader(decoded.String()), nil
+}
+
+// parseFeed Reads the items with media enclosures of an RSS 2.0 or Atom feed, in the order of the document.
+func parseFeed(data []byte) ([]feedItem, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = feedCharsetReader
+
+	var root xml.StartElement
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return nil, fmt.Errorf("invalid feed: %w", err)
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			root = start
+			break
+		}
+	}
+
+	switch root.Name.Local {
+	case "rss":
+		var feed rssFeed
+		if err := decoder.DecodeElement(&feed, &root); err != nil {
+			return nil, fmt.Errorf("invalid rss feed: %w", err)
+		}
+		return rssItems(feed), nil
+	case "feed":
+		var feed atomFeed
+		if err := decoder.DecodeElement(&feed, &root); err != nil {
+			return nil, fmt.Errorf("invalid atom feed: %w", err)
+		}
+		return atomItems(feed), nil
+	}
+
+	return nil, fmt.Errorf("unknown feed format '%s'", root.Name.Local)
+}
+
+func rssItems(feed rssFeed) []feedItem {
+	items := make([]feedItem, 0, len(feed.Items))
+	for _, item := range feed.Items {
+		enclosure := item.Enclosure
+		if enclosure.URL == "" && len(item.Media) > 0 {
+			enclosure = item.Media[0]
+		}
+		if enclosure.URL == "" {
+			continue
+		}
+		items = append(items, newFeedItem(item.GUID, item.Title, item.Description, parseFeedDate(item.PubDate), enclosure.URL, enclosure.Type))
+	}
+	return items
+}
+
+func atomItems(feed atomFeed) []feedItem {
+	items := make([]feedItem, 0, len(feed.Entries))
+	for _, entry := range feed.Entries {
+		index := slices.IndexFunc(entry.Links, func(link atomLink) bool { return link.Rel == "enclosure" && link.Href != "" })
+		if index < 0 {
+			continue
+		}
+		published := parseFeedDate(entry.Published)
+		if published == nil {
+			published = parseFeedDate(entry.Updated)
+		}
+		description := entry.Summary
+		if description == "" {
+			description = entry.Content
+		}
+		link := entry.Links[index]
+		items = append(items, newFeedItem(entry.ID, entry.Title, description, published, link.Href, link.Type))
+	}
+	return items
+}
+
+// newFeedItem Items without an id are identified by their enclosure.
+func newFeedItem(guid, title, description string, publishedAt *time.Time, enclosure, mediaType string) feedItem {
+	guid = strings.TrimSpace(guid)
+	if guid == "" {
+		guid = enclosure
+	}
+	return feedItem{
+		GUID:        guid,
+		Title:       strings.TrimSpace(title),
+		Description: strings.TrimSpace(description),
+		PublishedAt: publishedAt,
+		URL:         strings.TrimSpace(enclosure),
+		MediaType:   mediaType,
+	}
+}
+
+// mediaExtension The extension of the URL, if it links a video or audio file which can be kept as is.
+func mediaExtension(target string) (string, bool) {
+	parsed, err := url.Parse(target)
+	if err != nil {
+		return "", false
+	}
+	extension := strings.ToLower(path.Ext(parsed.Path))
+	if extension == ".mp4" || extension == ".m4v" || extension == ".mov" || helpers.IsAudioFile(extension) {
+		return extension, true
+	}
+	return "", false
+}
+
+// feedItemExtension The extension of the downloaded file, from the enclosure URL or otherwise its media type.
+func feedItemExtension(item feedItem) string {
+	if extension, ok := mediaExtension(item.URL); ok {
+		return extension
+	}
+	if mediaType, _, err := mime.ParseMediaType(item.MediaType); err == nil {
+		if extension, ok := feedExtensions[mediaType]; ok {
+			return extension
+		}
+		if strings.HasPrefix(mediaType, "audio/") {
+			return ".mp3"
+		}
+	}
+	return ".mp4"
+}
+
+// newHTTPClient A client which connects via the proxy of a channel, if it has one.
+func newHTTPClient(proxy string) (*http.Client, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy '%s': %w", proxy, err)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// channelGet Requests the URL with the headers and user agent of the channel.
+func channelGet(ctx context.Context, channel *database.Channel, target string) (*http.Response, error) {
+	client, err := newHTTPClient(channel.Proxy)
+	if err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range channel.Headers {
+		request.Header.Set(key, value)
+	}
+	if channel.UserAgent != "" {
+		request.Header.Set("User-Agent", channel.UserAgent)
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode != http.StatusOK {
+		_ = response.Body.Close()
+		return nil, fmt.Errorf("'%s' responded with '%s'", target, response.Status)
+	}
+
+	return response, nil
+}
+
+// fetchFeed Requests and parses the feed of the channel.
+func fetchFeed(ctx context.Context, channel *database.Channel) ([]feedItem, error) {
+	response, err := channelGet(ctx, channel, channel.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(io.LimitReader(response.Body, maxFeedSize))
+	if err != nil {
+		return nil, err
+	}
+
+	return parseFeed(data)
+}
+
+// progressWriter Reports the number of bytes written in intervals.
+type progressWriter struct {
+	writer     io.Writer
+	written    int64
+	reportedAt time.Time
+	onProgress func(written int64)
+}
+
+func (progress *progressWriter) Write(p []byte) (int, error) {
+	n, err := progress.writer.Write(p)
+	progress.written += int64(n)
+	if progress.onProgress != nil && time.Since(progress.reportedAt) >= progressEventInterval {
+		progress.reportedAt = time.Now()
+		progress.onProgress(progress.written)
+	}
+	return n, err
+}
+
+// downloadFile Writes the response into a temporary file, which replaces the output once it is complete.
+// onProgress receives the bytes written and the total size, which is 0 if the server does not send it.
+func downloadFile(ctx context.Context, channel *database.Channel, target, outputPath string, onProgress func(written, total int64)) error {
+	response, err := channelGet(ctx, channel, target)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	partPath := outputPath + ".part"
+	file, err := os.Create(partPath)
+	if err != nil {
+		return err
+	}
+
+	writer := &progressWriter{writer: file, onProgress: func(written int64) {
+		if onProgress != nil {
+			onProgress(written, max(response.ContentLength, 0))
+		}
+	}}
+
+	_, errCopy := io.Copy(writer, response.Body)
+	errClose := file.Close()
+	if err := errors.Join(errCopy, errClose); err != nil {
+		_ = os.Remove(partPath)
+		return fmt.Errorf("error downloading '%s': %w", target, err)
+	}
+	if response.ContentLength > 0 && writer.written != response.ContentLength {
+		_ = os.Remove(partPath)
+		return fmt.Errorf("download of '%s' is incomplete: %d of %d bytes", target, writer.written, response.ContentLength)
+	}
+
+	return os.Rename(partPath, outputPath)
+}
+
+// isBackfilled On the first poll of a channel only its latest items up to the backfill limit are downloaded,
+// the older ones are only marked as known. A limit of 0 downloads all items. The index counts from the latest item.
+func isBackfilled(channel *database.Channel, firstPoll bool, index int) bool {
+	return !firstPoll || channel.Backfill == 0 || index < int(channel.Backfill)
+}
+
+// enqueueItemDownload Creates the recording of a new item and enqueues its download.
+// If either fails the item is deleted again, so it isn't skipped as known at the next poll.
+func enqueueItemDownload(item *database.ChannelItem, extension string) error {
+	recording, err := item.CreateRecording(extension)
+	if err != nil {
+		forgetItem(item)
+		return err
+	}
+	network.BroadCastClients(network.RecordingStatusEvent, recording)
+
+	if _, err := recording.EnqueueDownloadJob(item.URL); err != nil {
+		if errDestroy := recording.DestroyRecording(); errDestroy != nil {
+			log.Errorf("[Feed] Error deleting recording of item '%s': %s", item.GUID, errDestroy)
+		}
+		forgetItem(item)
+		return err
+	}
+
+	return nil
+}
+
+func forgetItem(item *database.ChannelItem) {
+	if err := item.Delete(); err != nil {
+		log.Errorf("[Feed] %s", err)
+	}
+}
+
+// checkFeed Polls the feed of the channel and enqueues the download of each episode which is not known yet.
+// The first poll only downloads the latest episodes up to the backfill limit.
+// Returns the number of new episodes.
+func checkFeed(channel *database.Channel) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	items, err := fetchFeed(ctx, channel)
+	if err != nil {
+		return 0, err
+	}
+
+	known, err := channel.ChannelID.CountChannelItems()
+	if err != nil {
+		return 0, err
+	}
+
+	added := 0
+	// Feeds list the latest episode first, the older ones are downloaded first.
+	for i := len(items) - 1; i >= 0; i-- {
+		item := items[i]
+		channelItem := &database.ChannelItem{
+			ChannelID:   channel.ChannelID,
+			GUID:        item.GUID,
+			Title:       item.Title,
+			Description: item.Description,
+			PublishedAt: item.PublishedAt,
+			URL:         item.URL,
+			MediaType:   item.MediaType,
+		}
+
+		isNew, errAdd := database.AddChannelItem(channelItem)
+		if errAdd != nil {
+			return added, errAdd
+		}
+		if !isNew || !isBackfilled(channel, known == 0, i) {
+			continue
+		}
+
+		if err := enqueueItemDownload(channelItem, feedItemExtension(item)); err != nil {
+			return added, err
+		}
+
+		log.Infof("[Feed] New episode '%s' of %s", item.Title, channel.ChannelName)
+		added++
+	}
+
+	return added, nil
+}
+
+// pollFeed Checks the feed and schedules the next check, the feed is polled in a fixed interval.
+func pollFeed(channel *database.Channel) {
+	added, err := checkFeed(channel)
+
+	logResolverError(channel.ChannelID, err)
+	if err != nil {
+		log.Warnf("[Feed] Error checking feed of %s: %v", channel.ChannelName, err)
+		recordPoll(channel.ChannelID, false, time.Now())
+		return
+	}
+
+	log.Debugf("[Feed] %d new episodes of %s", added, channel.ChannelName)
+	schedulePoll(channel.ChannelID, feedPollInterval, time.Now())
+}
+
+// processDownload Downloads the enclosure of a feed item, the video of an archive or a fetched URL into the file of its recording.
+// The file is validated with ffprobe before the recording becomes ready.
+func processDownload(job *database.Job) error {
+	target, err := database.UnmarshalJobArg[string](job)
+	if err != nil {
+		return err
+	}
+	if target == nil {
+		return errors.New("download job without url")
+	}
+
+	recording := &job.Recording
+	// The recording might have been deleted in the meantime.
+	if recording.Status != database.RecordingStatusDownloading {
+		return nil
+	}
+
+	channel, err := database.GetChannelByID(job.ChannelID)
+	if err != nil {
+		return err
+	}
+	if errMkDir := channel.ChannelName.MkDir(); errMkDir != nil && !os.IsExist(errMkDir) {
+		return errMkDir
+	}
+
+	outputPath := recording.AbsoluteChannelFilepath()
+	log.Infof("[Job] Downloading '%s' to '%s'", *target, outputPath)
+
+	ctx, cancel := context.WithTimeout(context.Background(), downloadTimeout)
+	defer cancel()
+
+	onProgress := func(written, total int64) {
+		network.BroadCastClients(network.JobProgressEvent, JobMessage[helpers.TaskProgress]{
+			Job:  job,
+			Data: helpers.TaskProgress{Current: uint64(written), Total: uint64(total), Steps: 1, Step: 1, Message: "Downloading"},
+		})
+	}
+
+	item, err := recording.RecordingID.FindChannelItem()
+	if err != nil {
+		return err
+	}
+
+	var errDownload error
+	if isDirectDownload(channel, item, *target) {
+		errDownload = downloadFile(ctx, channel, *target, outputPath, onProgress)
+	} else {
+		errDownload = downloadVideo(ctx, channel, recording, item, *target, onProgress)
+	}
+	if errDownload != nil {
+		setRecordingStatus(recording, database.RecordingStatusFailed)
+		return errDownload
+	}
+
+	info, err := (&helpers.Video{FilePath: outputPath}).GetVideoInfo()
+	if err != nil {
+		setRecordingStatus(recording, database.RecordingStatusFailed)
+		return fmt.Errorf("error reading video information of '%s': %w", outputPath, err)
+	}
+	if info.Duration <= 0 {
+		setRecordingStatus(recording, database.RecordingStatusFailed)
+		return fmt.Errorf("'%s' has no playable media", *target)
+	}
+	if err := recording.UpdateInfo(info); err != nil {
+		log.Errorf("[Job] Error updating video info of '%s': %s", recording.Filename, err)
+	}
+
+	setRecordingStatus(recording, database.RecordingStatusReady)
+	network.BroadCastClients(network.RecordingAddEvent, recording)
+
+	if _, _, errPreviews := recording.EnqueuePreviewsJob(); errPreviews != nil {
+		return errPreviews
+	}
+
+	return nil
+}
+
+// GetChannelItems The episodes of a feed channel or the videos of an archive channel.
+func GetChannelItems(id database.ChannelID) ([]*database.ChannelItem, error) {
+	return id.FindChannelItems()
+}
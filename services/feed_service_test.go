@@ -0,0 +1,189 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+const rssFixture = `<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0" xmlns:media="http://search.yahoo.com/mrss/">
+  <channel>
+    <title>Podcast</title>
+    <item>
+      <guid isPermaLink="false">episode-2</guid>
+      <title>Episode 2</title>
+      <description><![CDATA[<p>The second episode</p>]]></description>
+      <pubDate>Tue, 05 Mar 2024 06:00:00 +0000</pubDate>
+      <enclosure url="%s/episode-2.mp3" type="audio/mpeg" length="4"/>
+    </item>
+    <item>
+      <title>Episode 1</title>
+      <pubDate>Mon, 4 Mar 2024 06:00:00 GMT</pubDate>
+      <media:content url="%s/episode-1" type="video/mp4"/>
+    </item>
+    <item>
+      <guid>announcement</guid>
+      <title>Without enclosure</title>
+    </item>
+  </channel>
+</rss>`
+
+const atomFixture = `<?xml version="1.0" encoding="utf-8"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Videos</title>
+  <entry>
+    <id>urn:uuid:1225c695</id>
+    <title>Video</title>
+    <summary>A video</summary>
+    <updated>2024-03-05T06:00:00Z</updated>
+    <link rel="alternate" href="https://example.com/video"/>
+    <link rel="enclosure" type="video/mp4" href="https://example.com/video.mp4"/>
+  </entry>
+  <entry>
+    <id>urn:uuid:1225c696</id>
+    <title>Text only</title>
+    <link href="https://example.com/text"/>
+  </entry>
+</feed>`
+
+func rssFeedFixture(baseURL string) string {
+	return fmt.Sprintf(rssFixture, baseURL, baseURL)
+}
+
+func TestParseFeedRSS(t *testing.T) {
+	items, err := parseFeed([]byte(rssFeedFixture("https://example.com")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("items are %+v", items)
+	}
+
+	first := items[0]
+	if first.GUID != "episode-2" || first.Title != "Episode 2" || first.Description != "<p>The second episode</p>" {
+		t.Errorf("first item is %+v", first)
+	}
+	if first.PublishedAt == nil || !first.PublishedAt.Equal(time.Date(2024, 3, 5, 6, 0, 0, 0, time.UTC)) {
+		t.Errorf("publish date is %v", first.PublishedAt)
+	}
+	if feedItemExtension(first) != ".mp3" {
+		t.Errorf("extension is %s", feedItemExtension(first))
+	}
+
+	// Without guid, the media enclosure identifies the item.
+	second := items[1]
+	if second.GUID != "https://example.com/episode-1" || second.PublishedAt == nil {
+		t.Errorf("second item is %+v", second)
+	}
+	if feedItemExtension(second) != ".mp4" {
+		t.Errorf("extension is %s", feedItemExtension(second))
+	}
+}
+
+func TestParseFeedAtom(t *testing.T) {
+	items, err := parseFeed([]byte(atomFixture))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 1 {
+		t.Fatalf("items are %+v", items)
+	}
+	if item := items[0]; item.GUID != "urn:uuid:1225c695" || item.URL != "https://example.com/video.mp4" || item.Description != "A video" || item.PublishedAt == nil {
+		t.Errorf("item is %+v", item)
+	}
+}
+
+func TestParseFeedUnknown(t *testing.T) {
+	if _, err := parseFeed([]byte(`<html><body></body></html>`)); err == nil {
+		t.Error("parseFeed() should reject html")
+	}
+}
+
+func TestParseFeedEncoding(t *testing.T) {
+	feed := "<?xml version=\"1.0\" encoding=\"%s\"?><rss><channel><item><guid>1</guid><title>Caf\xe9 \x93live\x94</title>" +
+		"<enclosure url=\"https://example.com/1.mp3\" type=\"audio/mpeg\"/></item></channel></rss>"
+
+	items, err := parseFeed([]byte(fmt.Sprintf(feed, "ISO-8859-1")))
+	if err != nil || len(items) != 1 || items[0].Title != "Café \u0093live\u0094" {
+		t.Errorf("parseFeed(ISO-8859-1) = %+v, %v", items, err)
+	}
+	items, err = parseFeed([]byte(fmt.Sprintf(feed, "windows-1252")))
+	if err != nil || len(items) != 1 || items[0].Title != "Café “live”" {
+		t.Errorf("parseFeed(windows-1252) = %+v, %v", items, err)
+	}
+	if _, err := parseFeed([]byte(fmt.Sprintf(feed, "Shift_JIS"))); err == nil {
+		t.Error("parseFeed() should reject encodings it can't decode")
+	}
+}
+
+func TestFetchFeedAndDownload(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("User-Agent") != "mediasink-test" {
+			t.Errorf("request without user agent of the channel: %q", r.Header.Get("User-Agent"))
+		}
+		switch r.URL.Path {
+		case "/feed.xml":
+			w.Header().Set("Content-Type", "application/rss+xml")
+			_, _ = w.Write([]byte(rssFeedFixture(server.URL)))
+		case "/episode-2.mp3":
+			w.Header().Set("Content-Type", "audio/mpeg")
+			_, _ = w.Write([]byte("ID3!"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	channel := &database.Channel{URL: server.URL + "/feed.xml", UserAgent: "mediasink-test"}
+
+	items, err := fetchFeed(context.Background(), channel)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(items) != 2 || items[0].URL != server.URL+"/episode-2.mp3" {
+		t.Fatalf("items are %+v", items)
+	}
+
+	output := filepath.Join(t.TempDir(), "episode.mp3")
+	if err := downloadFile(context.Background(), channel, items[0].URL, output, nil); err != nil {
+		t.Fatal(err)
+	}
+	if data, err := os.ReadFile(output); err != nil || string(data) != "ID3!" {
+		t.Errorf("downloaded file is %q (%v)", data, err)
+	}
+
+	// A failed download leaves no file behind.
+	missing := filepath.Join(t.TempDir(), "missing.mp4")
+	if err := downloadFile(context.Background(), channel, items[1].URL, missing, nil); err == nil {
+		t.Error("downloadFile() should fail for a missing enclosure")
+	}
+	if _, err := os.Stat(missing + ".part"); !os.IsNotExist(err) {
+		t.Error("partial download has not been removed")
+	}
+}
+
+func TestIsBackfilled(t *testing.T) {
+	channel := &database.Channel{Backfill: 2}
+
+	for index, want := range []bool{true, true, false, false} {
+		if got := isBackfilled(channel, true, index); got != want {
+			t.Errorf("isBackfilled(first poll, %d) = %v, want %v", index, got, want)
+		}
+	}
+	// Later polls download every new item.
+	if !isBackfilled(channel, false, 10) {
+		t.Error("new items after the first poll must be downloaded")
+	}
+	if !isBackfilled(&database.Channel{}, true, 10) {
+		t.Error("without a backfill limit the first poll downloads all items")
+	}
+}
@@ -0,0 +1,47 @@
+package services
+
+import (
+	"fmt"
+	"net/url"
+	"path"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/network"
+)
+
+// isDirectDownload Feed enclosures and links to media files are requested directly, video pages are downloaded by yt-dlp.
+func isDirectDownload(channel *database.Channel, item *database.ChannelItem, target string) bool {
+	if item != nil {
+		return channel.Type == database.ChannelTypeFeed
+	}
+	_, ok := mediaExtension(target)
+	return ok
+}
+
+// FetchURL Enqueues the download of a single video into the channel, from a video page or a link to a media file.
+// The recording is listed as downloading until the job has validated the file.
+func FetchURL(id database.ChannelID, target string) (*database.Job, error) {
+	parsed, err := url.Parse(target)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return nil, fmt.Errorf("url '%s' must be an http(s) url", target)
+	}
+
+	// Files are kept in their container, the title of pages is taken from the yt-dlp metadata.
+	extension, title := ".mp4", ""
+	if fileExtension, ok := mediaExtension(target); ok {
+		extension = fileExtension
+		title = strings.TrimSuffix(path.Base(parsed.Path), path.Ext(parsed.Path))
+	}
+
+	recording, err := database.CreateDownloadRecording(id, extension, title)
+	if err != nil {
+		return nil, err
+	}
+	network.BroadCastClients(network.RecordingStatusEvent, recording)
+
+	log.Infof("[Fetch] Enqueuing download of '%s' into %s", target, recording.ChannelName)
+
+	return recording.EnqueueDownloadJob(target)
+}
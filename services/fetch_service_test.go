@@ -0,0 +1,34 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestIsDirectDownload(t *testing.T) {
+	feed := &database.Channel{Type: database.ChannelTypeFeed}
+	archive := &database.Channel{Type: database.ChannelTypeArchive}
+	item := &database.ChannelItem{}
+
+	if !isDirectDownload(feed, item, "https://example.com/episode") {
+		t.Error("feed enclosures should be requested directly")
+	}
+	if isDirectDownload(archive, item, "https://example.com/video.mp4") {
+		t.Error("archive videos should be downloaded by yt-dlp")
+	}
+	if !isDirectDownload(&database.Channel{}, nil, "https://example.com/files/Show.M4A?token=1") {
+		t.Error("fetched media files should be requested directly")
+	}
+	if isDirectDownload(&database.Channel{}, nil, "https://www.youtube.com/watch?v=abc") {
+		t.Error("fetched video pages should be downloaded by yt-dlp")
+	}
+}
+
+func TestFetchURLRejectsInvalidURL(t *testing.T) {
+	for _, target := range []string{"", "ftp://example.com/video.mp4", "/recordings/video.mp4", "https://"} {
+		if _, err := FetchURL(1, target); err == nil {
+			t.Errorf("FetchURL(%q) should return an error", target)
+		}
+	}
+}
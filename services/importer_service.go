@@ -101,14 +101,14 @@ func ImportChannels(context.Context) error {
 		}
 
 		// ---------------------------------------------------------------------------------
-		// Traverse all mp4 files and add to models if not existent
+		// Traverse all mp4 and audio files and add to models if not existent
 		// ---------------------------------------------------------------------------------
 		var j = 0
-		log.Infof("[Import/%s (%d/%d)] Traverse all mp4 files and add to models if not existent (files: %d) ...", channelName, importProgress, importSize, len(files))
+		log.Infof("[Import/%s (%d/%d)] Traverse all mp4 and audio files and add to models if not existent (files: %d) ...", channelName, importProgress, importSize, len(files))
 		for _, file := range files {
 			j++
-			mp4File := !file.IsDir() && filepath.Ext(file.Name()) == ".mp4"
-			if !mp4File {
+			mediaFile := !file.IsDir() && (filepath.Ext(file.Name()) == ".mp4" || helpers.IsAudioFile(file.Name()))
+			if !mediaFile {
 				continue
 			}
 
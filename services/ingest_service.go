@@ -0,0 +1,276 @@
+package services
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/network"
+	"github.com/srad/mediasink/resolvers"
+)
+
+// ingestApp Application name of the RTMP URL, publishers push to rtmp://host:port/live/<stream key>.
+const ingestApp = "live"
+
+// ingestRejections ffmpeg only warns if an RTMP publisher presents another stream key or application,
+// so the listener is killed when one of these is logged. SRT listeners reject another passphrase themselves.
+var ingestRejections = []string{"Unexpected stream", "App field don't match up"}
+
+// ingestPublish The state of the publish, as followed in the log of the listener, see nextIngestPublish.
+type ingestPublish int
+
+const (
+	ingestPending ingestPublish = iota
+	ingestAccepted
+	ingestRejected
+)
+
+// ingestListener The listener goroutine of an ingest channel, it is restarted when the settings change.
+type ingestListener struct {
+	settings string
+	cancel   context.CancelFunc
+}
+
+// ingestListenURL The address the listener of the channel is bound to, without the stream key.
+func ingestListenURL(channel *database.Channel) string {
+	if channel.IngestProtocol == database.IngestProtocolSRT {
+		return fmt.Sprintf("srt://0.0.0.0:%d", channel.IngestPort)
+	}
+	return fmt.Sprintf("rtmp://0.0.0.0:%d/%s", channel.IngestPort, ingestApp)
+}
+
+// ingestListenArgs ffmpeg waits for one publisher and writes the received stream to stdout, which is then captured.
+// The stream key is the last element of the RTMP path, or the passphrase of the SRT connection.
+// The log level includes the description of the input, which ends the handshake, see nextIngestPublish.
+func ingestListenArgs(channel *database.Channel) []string {
+	args := []string{"-hide_banner", "-loglevel", "info", "-nostats"}
+	if channel.IngestProtocol == database.IngestProtocolSRT {
+		args = append(args, "-i", fmt.Sprintf("%s?mode=listener&passphrase=%s", ingestListenURL(channel), channel.StreamKey))
+	} else {
+		args = append(args, "-listen", "1", "-i", fmt.Sprintf("%s/%s", ingestListenURL(channel), channel.StreamKey))
+	}
+	return append(args, "-c", "copy", "-f", "mpegts", "pipe:1")
+}
+
+func isIngestRejection(line string) bool {
+	for _, message := range ingestRejections {
+		if strings.Contains(line, message) {
+			return true
+		}
+	}
+	return false
+}
+
+// nextIngestPublish Follows the handshake in the log of the listener. ffmpeg describes the input once the publisher
+// sends data, even after it has warned about another stream key. So the publish is only accepted, if no rejection
+// has been logged before. The state doesn't change anymore once the publish has been accepted or rejected.
+func nextIngestPublish(state ingestPublish, line string) ingestPublish {
+	switch {
+	case state != ingestPending:
+		return state
+	case isIngestRejection(line):
+		return ingestRejected
+	case strings.HasPrefix(line, "Input #0"):
+		return ingestAccepted
+	}
+	return state
+}
+
+// startIngestWorker Keeps one listener running for every enabled ingest channel, as long as the recorder is active.
+func startIngestWorker(ctx context.Context) {
+	log.Infoln("[Ingest] Worker started.")
+	defer log.Infoln("[Ingest] Worker stopped.")
+
+	listeners := make(map[database.ChannelID]*ingestListener)
+	defer func() {
+		for _, listener := range listeners {
+			listener.cancel()
+		}
+	}()
+
+	ticker := time.NewTicker(ingestSyncInterval)
+	defer ticker.Stop()
+
+	for {
+		syncIngestListeners(ctx, listeners)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// syncIngestListeners Starts the listeners of new ingest channels and stops those of channels which have been
+// paused, deleted or are outside their schedule. A running capture ends when its listener is stopped.
+func syncIngestListeners(ctx context.Context, listeners map[database.ChannelID]*ingestListener) {
+	channels, err := database.EnabledChannelList()
+	if err != nil {
+		log.Errorf("[Ingest] Error fetching enabled channel list: %v", err)
+		return
+	}
+
+	wanted := make(map[database.ChannelID]*database.Channel)
+	for _, channel := range channels {
+		if channel.Type != database.ChannelTypeIngest {
+			continue
+		}
+		scheduled, errSchedule := isScheduled(channel.ChannelID, time.Now())
+		if errSchedule != nil {
+			log.Errorf("[Ingest] Error checking schedule of channel %s: %v", channel.ChannelName, errSchedule)
+			continue
+		}
+		if scheduled && DiskLevel() != DiskLevelCritical {
+			wanted[channel.ChannelID] = channel
+		}
+	}
+
+	for id, listener := range listeners {
+		if channel, ok := wanted[id]; !ok || listener.settings != ingestSettings(channel) {
+			log.Infof("[Ingest] Stopping listener of channel %d", id)
+			listener.cancel()
+			delete(listeners, id)
+		}
+	}
+
+	for id, channel := range wanted {
+		if _, ok := listeners[id]; ok {
+			continue
+		}
+		log.Infof("[Ingest] Listening for channel %s on %s", channel.ChannelName, ingestListenURL(channel))
+		listenerCtx, cancel := context.WithCancel(ctx)
+		listeners[id] = &ingestListener{settings: ingestSettings(channel), cancel: cancel}
+		go runIngestListener(listenerCtx, id)
+	}
+}
+
+// ingestSettings The listener is restarted if any of these change.
+func ingestSettings(channel *database.Channel) string {
+	return fmt.Sprintf("%s:%d:%s", channel.IngestProtocol, channel.IngestPort, channel.StreamKey)
+}
+
+// runIngestListener Waits for publishers until the context is cancelled, one publish at a time.
+func runIngestListener(ctx context.Context, id database.ChannelID) {
+	for {
+		// Re-fetch for the latest capture settings of each publish.
+		if channel, err := database.GetChannelByID(id); err != nil {
+			log.Errorf("[Ingest] Error querying channel %d: %v", id, err)
+		} else if errListen := listenIngest(ctx, channel); errListen != nil {
+			log.Errorf("[Ingest] Listener of %s failed: %v", channel.ChannelName, errListen)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ingestRetryDelay):
+		}
+	}
+}
+
+// listenIngest Runs the listener until the publisher disconnects. The published stream is captured like
+// a pulled stream, within a session of the channel.
+func listenIngest(ctx context.Context, channel *database.Channel) error {
+	listenCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	cmd := exec.CommandContext(listenCtx, "ffmpeg", ingestListenArgs(channel)...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var rejected atomic.Bool
+	handshake := make(chan ingestPublish, 1)
+	logged := make(chan struct{})
+	go func() {
+		defer close(logged)
+		state := ingestPending
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			line := scanner.Text()
+			previous := state
+			if state = nextIngestPublish(state, line); state == previous {
+				log.Debugf("[Ingest] %s: %s", channel.ChannelName, line)
+				continue
+			}
+			if state == ingestRejected {
+				rejected.Store(true)
+				log.Warnf("[Ingest] Rejecting publisher of %s: %s", channel.ChannelName, line)
+				addChannelSessionEvent(channel.ChannelID, nil, database.ChannelSessionEventError, "rejected publisher with unknown stream key")
+				cancel()
+			}
+			handshake <- state
+		}
+	}()
+
+	// Blocks until a publisher has been accepted, the output is not read before.
+	var errCapture error
+	select {
+	case state := <-handshake:
+		if state == ingestAccepted {
+			errCapture = captureIngest(listenCtx, cancel, channel, stdout)
+		}
+	case <-logged:
+	}
+
+	cancel()
+	<-logged
+	errWait := cmd.Wait()
+
+	if errCapture != nil {
+		return errCapture
+	}
+	// The listener is killed when it is stopped or the publisher has been rejected.
+	if errWait != nil && listenCtx.Err() == nil && !rejected.Load() {
+		var exitErr *exec.ExitError
+		if errors.As(errWait, &exitErr) {
+			return fmt.Errorf("ffmpeg exited with %d", exitErr.ExitCode())
+		}
+		return errWait
+	}
+
+	return nil
+}
+
+// captureIngest Captures the published stream, the channel is online until the publisher disconnects.
+func captureIngest(ctx context.Context, cancel context.CancelFunc, channel *database.Channel, source io.Reader) error {
+	id := channel.ChannelID
+	log.Infof("[Ingest] Publisher connected to channel %s", channel.ChannelName)
+
+	streamInfoLock.Lock()
+	streamInfo[id] = StreamInfo{
+		IsOnline:    true,
+		URL:         ingestListenURL(channel),
+		ChannelName: channel.ChannelName,
+		AudioOnly:   channel.CapturesAudioOnly(),
+		Ingest:      true,
+	}
+	streamInfoLock.Unlock()
+
+	network.BroadCastClients(network.ChannelOnlineEvent, id)
+	network.BroadCastClients(network.ChannelStartEvent, id)
+
+	err := capturePipe(ctx, cancel, channel, &resolvers.Result{URL: ingestListenURL(channel)}, source, channel.SkipStart, nil)
+
+	DeleteStreamData(id)
+	network.BroadCastClients(network.ChannelOfflineEvent, id)
+	log.Infof("[Ingest] Publisher of channel %s disconnected", channel.ChannelName)
+
+	return err
+}
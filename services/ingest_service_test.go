@@ -0,0 +1,100 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestIngestListenArgs(t *testing.T) {
+	tests := []struct {
+		name    string
+		channel database.Channel
+		input   string
+		listen  bool
+	}{
+		{
+			name:    "rtmp by default",
+			channel: database.Channel{IngestPort: 1935, StreamKey: "0123456789abcdef"},
+			input:   "rtmp://0.0.0.0:1935/live/0123456789abcdef",
+			listen:  true,
+		},
+		{
+			name:    "srt passphrase",
+			channel: database.Channel{IngestProtocol: database.IngestProtocolSRT, IngestPort: 9000, StreamKey: "0123456789abcdef"},
+			input:   "srt://0.0.0.0:9000?mode=listener&passphrase=0123456789abcdef",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			args := strings.Join(ingestListenArgs(&tt.channel), " ")
+			if !strings.Contains(args, "-i "+tt.input+" ") {
+				t.Errorf("args %q do not read from %q", args, tt.input)
+			}
+			if strings.Contains(args, "-listen 1") != tt.listen {
+				t.Errorf("args %q: listen flag should be %v", args, tt.listen)
+			}
+			if !strings.HasSuffix(args, "-c copy -f mpegts pipe:1") {
+				t.Errorf("args %q do not write the stream to stdout", args)
+			}
+		})
+	}
+}
+
+func TestIngestListenURLHidesStreamKey(t *testing.T) {
+	channel := &database.Channel{IngestPort: 1935, StreamKey: "0123456789abcdef"}
+	if url := ingestListenURL(channel); strings.Contains(url, channel.StreamKey) {
+		t.Errorf("listen url %q contains the stream key", url)
+	}
+}
+
+func TestIsIngestRejection(t *testing.T) {
+	lines := map[string]bool{
+		"[rtmp @ 0x55d0c8a4e2c0] Unexpected stream wrongkey, expecting 0123456789abcdef": true,
+		"[rtmp @ 0x55d0c8a4e2c0] App field don't match up: other <-> live":               true,
+		"[flv @ 0x55d0c8a4e2c0] Packet mismatch 123 456 789":                             false,
+	}
+
+	for line, want := range lines {
+		if got := isIngestRejection(line); got != want {
+			t.Errorf("isIngestRejection(%q) = %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestNextIngestPublish(t *testing.T) {
+	// The log of an RTMP listener, ffmpeg describes the input of a publisher with a wrong key anyway.
+	wrongKey := []string{
+		"[rtmp @ 0x55d0c8a4e2c0] Unexpected stream wrongkey, expecting 0123456789abcdef",
+		"Input #0, flv, from 'rtmp://0.0.0.0:1935/live/0123456789abcdef':",
+		"  Stream #0:0: Video: h264 (High), yuv420p(progressive), 1920x1080, 30 fps",
+	}
+	validKey := []string{
+		"Input #0, flv, from 'rtmp://0.0.0.0:1935/live/0123456789abcdef':",
+		"  Stream #0:0: Video: h264 (High), yuv420p(progressive), 1920x1080, 30 fps",
+	}
+
+	tests := []struct {
+		name  string
+		lines []string
+		want  ingestPublish
+	}{
+		{"wrong key", wrongKey, ingestRejected},
+		{"valid key", validKey, ingestAccepted},
+		{"no publisher", []string{"[srt @ 0x55d0c8a4e2c0] Connection to srt://0.0.0.0:9000 failed"}, ingestPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			state := ingestPending
+			for _, line := range tt.lines {
+				state = nextIngestPublish(state, line)
+			}
+			if state != tt.want {
+				t.Errorf("state is %d, want %d", state, tt.want)
+			}
+		})
+	}
+}
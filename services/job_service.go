@@ -2,9 +2,12 @@ package services
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/srad/mediasink/conf"
@@ -18,7 +21,9 @@ import (
 var (
 	sleepBetweenRounds  = 1 * time.Second
 	ctxJobs, cancelJobs = context.WithCancel(context.Background())
-	processing          = false
+	processing          atomic.Int32 // Number of running workers
+	// errJobPending The job can't run yet and is retried in a later round.
+	errJobPending = errors.New("job is pending")
 )
 
 type JobMessage[T any] struct {
@@ -26,18 +31,44 @@ type JobMessage[T any] struct {
 	Data T             `json:"data"`
 }
 
-func processJobs(ctx context.Context) {
+// nextJob Downloads have their own worker, so that a long back catalogue doesn't hold up the finalization of captures.
+// Low on disk space only recordings are finalized, all other jobs wait.
+func nextJob() (*database.Job, error) {
+	switch DiskLevel() {
+	case DiskLevelOK:
+		return database.GetNextJobExcept(database.TaskDownload)
+	case DiskLevelCritical:
+		job, err := database.GetNextJob(database.TaskFinalize)
+		if err != nil || job == nil || !hasRoomToFinalize(job) {
+			return nil, err
+		}
+		return job, nil
+	default:
+		return database.GetNextJob(database.TaskFinalize)
+	}
+}
+
+// nextDownload Downloads wait while the disk space is low.
+func nextDownload() (*database.Job, error) {
+	if DiskLevel() != DiskLevelOK {
+		return nil, nil
+	}
+	return database.GetNextJob(database.TaskDownload)
+}
+
+func processJobs(ctx context.Context, name string, next func() (*database.Job, error)) {
+	processing.Add(1)
+	defer processing.Add(-1)
+
 	for {
 		select {
 		case <-ctx.Done():
-			log.Infoln("[processJobs] Worker stopped")
-			processing = false
+			log.Infof("[%s] Worker stopped", name)
 			return
 		case <-time.After(sleepBetweenRounds):
-			processing = true
-			job, errNextJob := database.GetNextJob()
+			job, errNextJob := next()
 			if errNextJob != nil {
-				_ = job.Error(errNextJob)
+				log.Errorf("[%s] Error reading next job: %s", name, errNextJob)
 				continue
 			}
 			if job == nil {
@@ -68,8 +99,14 @@ func executeJob(job *database.Job) error {
 
 	switch job.Task {
 	case database.TaskPreviewCover:
+		if helpers.IsAudioFile(video.FilePath) {
+			return handleJob(job, processAudioCover(job, &video))
+		}
 		return handleJob(job, processPreviewCover(job, &video))
 	case database.TaskPreviewStrip:
+		if helpers.IsAudioFile(video.FilePath) {
+			return handleJob(job, processAudioStripe(job, &video))
+		}
 		return handleJob(job, processPreviewStrip(job, &video))
 	case database.TaskPreviewVideo:
 		// video jobs won't be created for now.
@@ -78,12 +115,24 @@ func executeJob(job *database.Job) error {
 		return handleJob(job, processCutting(job))
 	case database.TaskConvert:
 		return handleJob(job, processConversion(job))
+	case database.TaskFinalize:
+		return handleJob(job, processFinalize(job))
+	case database.TaskMerge:
+		return handleJob(job, processMerge(job))
+	case database.TaskClip:
+		return handleJob(job, processClip(job))
+	case database.TaskDownload:
+		return handleJob(job, processDownload(job))
 	}
 
 	return nil
 }
 
 func handleJob(job *database.Job, err error) error {
+	if errors.Is(err, errJobPending) {
+		log.Infof("[Job] Postponing job %d: %s", job.JobID, err)
+		return job.Postpone()
+	}
 	if err != nil {
 		errErrStore := job.Error(err)
 		network.BroadCastClients(network.JobErrorEvent, JobMessage[string]{Data: err.Error(), Job: job})
@@ -186,6 +235,25 @@ func processPreviewCover(job *database.Job, video *helpers.Video) error {
 	return job.Recording.UpdatePreviewPath(database.PreviewCover)
 }
 
+// processAudioCover Uses the embedded artwork of an audio recording, or its waveform.
+func processAudioCover(job *database.Job, video *helpers.Video) error {
+	info, err := video.GetVideoInfo()
+	if err != nil {
+		return err
+	}
+	if _, err := video.ExecAudioCover(job.ChannelName.AbsoluteChannelDataPath(), info.HasArtwork); err != nil {
+		return err
+	}
+	return job.Recording.UpdatePreviewPath(database.PreviewCover)
+}
+
+func processAudioStripe(job *database.Job, video *helpers.Video) error {
+	if _, err := video.ExecAudioStripe(job.ChannelName.AbsoluteChannelDataPath()); err != nil {
+		return err
+	}
+	return job.Recording.UpdatePreviewPath(database.PreviewStripe)
+}
+
 func processConversion(job *database.Job) error {
 	mediaType, err := database.UnmarshalJobArg[string](job)
 	if err != nil {
@@ -241,6 +309,333 @@ func processConversion(job *database.Job) error {
 	return nil
 }
 
+// hasRoomToFinalize The remux writes a copy of the capture, at critical disk usage the copy must fit into the free space.
+// Otherwise the job stays open until space has been freed.
+func hasRoomToFinalize(job *database.Job) bool {
+	stat, err := os.Stat(job.Recording.AbsoluteChannelFilepath())
+	if err != nil {
+		// Missing captures are handled by the job itself.
+		return true
+	}
+
+	info, err := helpers.DiskUsage(conf.Read().DataDisk)
+	if err != nil {
+		log.Errorf("[Job] Error reading disk usage: %s", err)
+		return false
+	}
+
+	// df rounds the available space up to whole gigabytes.
+	available := int64(info.AvailFormattedGb-1) << 30
+	if available < stat.Size() {
+		log.Debugf("[Job] Deferring finalization of '%s', %d bytes free, %d bytes needed", job.Recording.Filename, available, stat.Size())
+		return false
+	}
+
+	return true
+}
+
+// processFinalize Remuxes a capture into a faststart mp4 and replaces the capture file with it.
+// Captures of audio-only channels are written into the audio format of the channel instead.
+func processFinalize(job *database.Job) error {
+	recording := &job.Recording
+	// The recording might have been discarded in the meantime.
+	if !recording.Filename.IsCapture() || recording.Status != database.RecordingStatusFinalizing {
+		return nil
+	}
+
+	audioFormat, err := channelAudioFormat(job.ChannelID)
+	if err != nil {
+		return err
+	}
+	extension := ".mp4"
+	if audioFormat != nil {
+		extension = audioFormat.Extension
+	}
+
+	filename := recording.Filename.WithExtension(extension)
+	inputPath := recording.AbsoluteChannelFilepath()
+	outputPath := recording.ChannelName.AbsoluteChannelFilePath(filename)
+
+	log.Infof("[Job] Finalizing capture '%s' to '%s'", inputPath, outputPath)
+
+	onStart := func(info helpers.CommandInfo) {
+		_ = job.UpdateInfo(info.Pid, info.Command)
+	}
+	onErr := func(err error) {
+		network.BroadCastClients(network.JobErrorEvent, JobMessage[string]{Job: job, Data: err.Error()})
+	}
+
+	var errRemux error
+	if audioFormat != nil {
+		errRemux = convertCaptureAudio(&helpers.AudioConversionArgs{
+			OnStart:                onStart,
+			OnErr:                  onErr,
+			AbsoluteInputFilepath:  inputPath,
+			AbsoluteOutputFilepath: outputPath,
+			Format:                 *audioFormat,
+		})
+	} else {
+		errRemux = helpers.RemuxVideo(&helpers.RemuxArgs{
+			OnStart:                onStart,
+			OnErr:                  onErr,
+			AbsoluteInputFilepath:  inputPath,
+			AbsoluteOutputFilepath: outputPath,
+		})
+	}
+
+	if errRemux != nil {
+		// Keep the capture, the job can be retried.
+		if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+			log.Errorf("[Job] Error deleting remuxed file '%s': %s", outputPath, err)
+		}
+		setRecordingStatus(recording, database.RecordingStatusFailed)
+		return fmt.Errorf("error remuxing capture '%s': %w", inputPath, errRemux)
+	}
+
+	video := &helpers.Video{FilePath: outputPath}
+	info, errInfo := video.GetVideoInfo()
+	if errInfo != nil {
+		if err := os.Remove(outputPath); err != nil {
+			log.Errorf("[Job] Error deleting remuxed file '%s': %s", outputPath, err)
+		}
+		setRecordingStatus(recording, database.RecordingStatusFailed)
+		return fmt.Errorf("error reading video information of '%s': %w", outputPath, errInfo)
+	}
+
+	if err := recording.Rename(filename); err != nil {
+		return err
+	}
+	if err := recording.UpdateInfo(info); err != nil {
+		log.Errorf("[Job] Error updating video info of '%s': %s", filename, err)
+	}
+
+	if err := os.Remove(inputPath); err != nil {
+		log.Errorf("[Job] Error deleting capture '%s': %s", inputPath, err)
+	}
+
+	setRecordingStatus(recording, database.RecordingStatusReady)
+	network.BroadCastClients(network.RecordingAddEvent, recording)
+
+	if err := enforceRingBuffer(job.ChannelID); err != nil {
+		log.Errorf("[Job] Error enforcing ring buffer of channel %d: %s", job.ChannelID, err)
+	}
+
+	if _, _, errPreviews := recording.EnqueuePreviewsJob(); errPreviews != nil {
+		return errPreviews
+	}
+
+	return nil
+}
+
+// channelAudioFormat Returns the format of an audio-only channel, or nil if the channel records video.
+func channelAudioFormat(id database.ChannelID) (*helpers.AudioFormat, error) {
+	channel, err := database.GetChannelByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if !channel.CapturesAudioOnly() {
+		return nil, nil
+	}
+
+	format, err := channel.AudioFormat()
+	if err != nil {
+		return nil, err
+	}
+	return &format, nil
+}
+
+// convertCaptureAudio Probes the codec of the capture, so the audio is only re-encoded if it differs from the format.
+func convertCaptureAudio(args *helpers.AudioConversionArgs) error {
+	capture := &helpers.Video{FilePath: args.AbsoluteInputFilepath}
+	info, err := capture.GetVideoInfo()
+	if err != nil {
+		return err
+	}
+	args.SourceCodec = info.AudioCodec
+
+	return helpers.ConvertAudio(args)
+}
+
+// processMerge Concatenates all finalized parts of a session into a single recording and destroys the parts.
+// checkPartsFinalized The merge waits for the finalize jobs of the parts which are still pending.
+func checkPartsFinalized(sessionID database.SessionID, pending []*database.Recording) error {
+	if len(pending) > 0 {
+		return fmt.Errorf("session %s has %d parts which are not finalized yet: %w", sessionID, len(pending), errJobPending)
+	}
+	return nil
+}
+
+func processMerge(job *database.Job) error {
+	sessionID, err := database.UnmarshalJobArg[database.SessionID](job)
+	if err != nil {
+		return err
+	}
+
+	// A part which has not been finalized yet would be missing from the merged recording.
+	pending, err := sessionID.FindRecordings(database.RecordingStatusRecording, database.RecordingStatusFinalizing)
+	if err != nil {
+		return err
+	}
+	if err := checkPartsFinalized(*sessionID, pending); err != nil {
+		return err
+	}
+
+	parts, err := sessionID.FindRecordings(database.RecordingStatusReady)
+	if err != nil {
+		return err
+	}
+	if len(parts) < 2 {
+		return nil
+	}
+
+	log.Infof("[Job] Merging %d parts of session %s", len(parts), *sessionID)
+
+	stamp := parts[0].CreatedAt.Format("2006_01_02_15_04_05")
+	filename := database.RecordingFileName(fmt.Sprintf("%s_session_%s%s", job.ChannelName, stamp, filepath.Ext(parts[0].Filename.String())))
+	outputFile := job.ChannelName.AbsoluteChannelFilePath(filename)
+
+	mergeFileContent := make([]string, len(parts))
+	for i, part := range parts {
+		mergeFileContent[i] = fmt.Sprintf("file '%s'", part.AbsoluteChannelFilepath())
+	}
+	mergeFileAbsolutePath := job.ChannelName.AbsoluteChannelFilePath(database.RecordingFileName(fmt.Sprintf("%s_session_%s.txt", job.ChannelName, stamp)))
+	if err := os.WriteFile(mergeFileAbsolutePath, []byte(strings.Join(mergeFileContent, "\n")), 0644); err != nil {
+		return fmt.Errorf("error writing concat text file %s: %w", mergeFileAbsolutePath, err)
+	}
+	defer func() {
+		if err := os.Remove(mergeFileAbsolutePath); err != nil {
+			log.Errorf("[Job] Error deleting merge file %s: %s", mergeFileAbsolutePath, err)
+		}
+	}()
+
+	errMerge := helpers.MergeVideos(&helpers.MergeArgs{
+		OnStart: func(info helpers.CommandInfo) {
+			_ = job.UpdateInfo(info.Pid, info.Command)
+		},
+		OnErr: func(err error) {
+			network.BroadCastClients(network.JobErrorEvent, JobMessage[string]{Job: job, Data: err.Error()})
+		},
+		MergeFileAbsolutePath:  mergeFileAbsolutePath,
+		AbsoluteOutputFilepath: outputFile,
+	})
+
+	// The parts are kept, i.e. if the stream changed its resolution, the parts cannot be concatenated.
+	if errMerge != nil {
+		if err := os.Remove(outputFile); err != nil && !os.IsNotExist(err) {
+			log.Errorf("[Job] Error deleting '%s': %s", outputFile, err)
+		}
+		return fmt.Errorf("error merging session %s: %w", *sessionID, errMerge)
+	}
+
+	merged, err := database.CreateSessionRecording(job.ChannelID, filename, "recording", *sessionID)
+	if err != nil {
+		return err
+	}
+
+	network.BroadCastClients(network.RecordingAddEvent, merged)
+
+	if _, _, errPreviews := merged.EnqueuePreviewsJob(); errPreviews != nil {
+		log.Errorf("[Job] Error enqueuing previews for '%s': %s", filename, errPreviews)
+	}
+
+	// The job belongs to the first part, it is kept with the merged recording.
+	if err := job.AttachRecording(merged); err != nil {
+		return err
+	}
+
+	for _, part := range parts {
+		if err := part.DestroyRecording(); err != nil {
+			log.Errorf("[Job] Error destroying part '%s': %s", part.Filename, err)
+		}
+	}
+
+	return nil
+}
+
+// processClip Extracts a range of a capture into a new recording, while the capture keeps writing the file.
+// MPEG-TS is readable up to the last written packet, so the range is copied like a regular cut.
+func processClip(job *database.Job) error {
+	clipArgs, err := database.UnmarshalJobArg[helpers.ClipArgs](job)
+	if err != nil {
+		return err
+	}
+
+	audioFormat, err := channelAudioFormat(job.ChannelID)
+	if err != nil {
+		return err
+	}
+	extension := ".mp4"
+	if audioFormat != nil {
+		extension = audioFormat.Extension
+	}
+
+	// The capture might have been finalized in the meantime, the job references the recording by id.
+	inputPath := job.Recording.AbsoluteChannelFilepath()
+	stamp := time.Now().Format("2006_01_02_15_04_05")
+	filename := database.RecordingFileName(fmt.Sprintf("%s_clip_%s%s", job.ChannelName, stamp, extension))
+	outputPath := job.ChannelName.AbsoluteChannelFilePath(filename)
+
+	log.Infof("[Job] Clipping %.0fs-%.0fs of '%s'", clipArgs.Start, clipArgs.End, inputPath)
+
+	var errCut error
+	if audioFormat != nil {
+		errCut = convertCaptureAudio(&helpers.AudioConversionArgs{
+			OnStart: func(info helpers.CommandInfo) {
+				_ = job.UpdateInfo(info.Pid, info.Command)
+			},
+			OnErr: func(err error) {
+				network.BroadCastClients(network.JobErrorEvent, JobMessage[string]{Job: job, Data: err.Error()})
+			},
+			AbsoluteInputFilepath:  inputPath,
+			AbsoluteOutputFilepath: outputPath,
+			Format:                 *audioFormat,
+			Start:                  fmt.Sprintf("%.3f", clipArgs.Start),
+			End:                    fmt.Sprintf("%.3f", clipArgs.End),
+		})
+	} else {
+		errCut = helpers.CutVideo(&helpers.CuttingJob{
+			OnStart: func(info *helpers.CommandInfo) {
+				_ = job.UpdateInfo(info.Pid, info.Command)
+				network.BroadCastClients(network.JobStartEvent, JobMessage[helpers.TaskInfo]{
+					Job: job,
+					Data: helpers.TaskInfo{
+						Steps:   1,
+						Step:    1,
+						Pid:     info.Pid,
+						Command: info.Command,
+						Message: "Starting clipping",
+					},
+				})
+			},
+			OnProgress: func(s string) {
+				network.BroadCastClients(network.JobProgressEvent, JobMessage[string]{Job: job, Data: s})
+			},
+		}, inputPath, outputPath, fmt.Sprintf("%.3f", clipArgs.Start), fmt.Sprintf("%.3f", clipArgs.End))
+	}
+
+	if errCut != nil {
+		if err := os.Remove(outputPath); err != nil && !os.IsNotExist(err) {
+			log.Errorf("[Job] Error deleting clip '%s': %s", outputPath, err)
+		}
+		return fmt.Errorf("error clipping '%s': %w", inputPath, errCut)
+	}
+
+	clip, errCreate := database.CreateRecording(job.ChannelID, filename, "clip")
+	if errCreate != nil {
+		if err := os.Remove(outputPath); err != nil {
+			log.Errorf("[Job] Error deleting clip '%s': %s", outputPath, err)
+		}
+		return errCreate
+	}
+	network.BroadCastClients(network.RecordingAddEvent, clip)
+
+	if _, _, errPreview := clip.EnqueuePreviewsJob(); errPreview != nil {
+		return errPreview
+	}
+
+	return nil
+}
+
 // Three-phase cutting job:
 // 1. Cut video at the given time intervals
 // 2. Merge the cuts
@@ -257,7 +652,8 @@ func processCutting(job *database.Job) error {
 	// Filenames
 	now := time.Now()
 	stamp := now.Format("2006_01_02_15_04_05")
-	filename := database.RecordingFileName(fmt.Sprintf("%s_cut_%s.mp4", job.ChannelName, stamp))
+	extension := filepath.Ext(job.Filename.String())
+	filename := database.RecordingFileName(fmt.Sprintf("%s_cut_%s%s", job.ChannelName, stamp, extension))
 	inputPath := job.ChannelName.AbsoluteChannelFilePath(job.Filename)
 	outputFile := job.ChannelName.AbsoluteChannelFilePath(filename)
 	segFiles := make([]string, len(cutArgs.Starts))
@@ -266,7 +662,7 @@ func processCutting(job *database.Job) error {
 	// Cut
 	segmentFilename := fmt.Sprintf("%s_cut_%s", job.ChannelName, stamp)
 	for i, start := range cutArgs.Starts {
-		segFiles[i] = job.ChannelName.AbsoluteChannelFilePath(database.RecordingFileName(fmt.Sprintf("%s_%04d.mp4", segmentFilename, i)))
+		segFiles[i] = job.ChannelName.AbsoluteChannelFilePath(database.RecordingFileName(fmt.Sprintf("%s_%04d%s", segmentFilename, i, extension)))
 		err = helpers.CutVideo(&helpers.CuttingJob{
 			OnStart: func(info *helpers.CommandInfo) {
 				_ = job.UpdateInfo(info.Pid, info.Command)
@@ -400,7 +796,8 @@ func DeleteJob(id uint) error {
 
 func StartJobProcessing() {
 	ctxJobs, cancelJobs = context.WithCancel(context.Background())
-	go processJobs(ctxJobs)
+	go processJobs(ctxJobs, "processJobs", nextJob)
+	go processJobs(ctxJobs, "processDownloads", nextDownload)
 }
 
 func StopJobProcessing() {
@@ -408,5 +805,5 @@ func StopJobProcessing() {
 }
 
 func IsJobProcessing() bool {
-	return processing
+	return processing.Load() > 0
 }
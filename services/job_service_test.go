@@ -0,0 +1,20 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestCheckPartsFinalized(t *testing.T) {
+	if err := checkPartsFinalized("session", nil); err != nil {
+		t.Errorf("checkPartsFinalized without pending parts is %s", err)
+	}
+
+	// A pending part postpones the merge instead of failing it.
+	pending := []*database.Recording{{RecordingID: 1, Status: database.RecordingStatusFinalizing}}
+	if err := checkPartsFinalized("session", pending); !errors.Is(err, errJobPending) {
+		t.Errorf("checkPartsFinalized with a pending part is %v, want errJobPending", err)
+	}
+}
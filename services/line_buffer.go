@@ -0,0 +1,53 @@
+package services
+
+import (
+	"strings"
+	"sync"
+)
+
+// lineBuffer Keeps the last lines written to it, i.e. the stderr of a long-running process.
+type lineBuffer struct {
+	lock    sync.Mutex
+	lines   []string
+	partial string
+	size    int
+}
+
+func newLineBuffer(size int) *lineBuffer {
+	return &lineBuffer{size: size}
+}
+
+func (buffer *lineBuffer) Write(p []byte) (int, error) {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	text := buffer.partial + string(p)
+	lines := strings.Split(text, "\n")
+	// The last element is either empty or an unterminated line.
+	buffer.partial = lines[len(lines)-1]
+
+	for _, line := range lines[:len(lines)-1] {
+		buffer.lines = append(buffer.lines, strings.TrimRight(line, "\r"))
+	}
+	if overflow := len(buffer.lines) - buffer.size; overflow > 0 {
+		buffer.lines = append([]string(nil), buffer.lines[overflow:]...)
+	}
+
+	return len(p), nil
+}
+
+// Lines The most recent lines, including an unterminated last line.
+func (buffer *lineBuffer) Lines() []string {
+	buffer.lock.Lock()
+	defer buffer.lock.Unlock()
+
+	lines := append([]string(nil), buffer.lines...)
+	if buffer.partial != "" {
+		lines = append(lines, buffer.partial)
+	}
+	return lines
+}
+
+func (buffer *lineBuffer) String() string {
+	return strings.Join(buffer.Lines(), "\n")
+}
@@ -0,0 +1,23 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLineBuffer(t *testing.T) {
+	buffer := newLineBuffer(3)
+
+	_, _ = buffer.Write([]byte("first\nsecond\nthi"))
+	_, _ = buffer.Write([]byte("rd\r\nfourth\nfif"))
+
+	// The unterminated last line is returned in addition to the complete ones.
+	expected := []string{"second", "third", "fourth", "fif"}
+	if lines := buffer.Lines(); !reflect.DeepEqual(lines, expected) {
+		t.Errorf("Lines() is %q but should be %q", lines, expected)
+	}
+
+	if s := newLineBuffer(3).String(); s != "" {
+		t.Errorf("String() of empty buffer is %q", s)
+	}
+}
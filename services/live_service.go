@@ -0,0 +1,116 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/helpers"
+	"gorm.io/gorm"
+)
+
+var ErrNotLive = errors.New("recording is not being captured")
+
+// LiveFile Absolute path of a file of the HLS playlist of the channel, which must currently be captured.
+// During a rollover the playlist switches to the new part as soon as it writes data.
+// The playlist itself only contains the live window.
+func LiveFile(id database.ChannelID, filename string) (string, error) {
+	recording := Info(id)
+	if recording == nil {
+		return "", ErrNotLive
+	}
+
+	return hlsFile(recording, filename)
+}
+
+// TimeshiftFile Absolute path of a file of the HLS playlist of a recording which is still being captured.
+// The playlist contains all segments from the start of the recording up to the live edge.
+func TimeshiftFile(id database.RecordingID, filename string) (string, error) {
+	recording, err := database.FindRecordingByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", ErrNotLive
+	}
+	if err != nil {
+		return "", err
+	}
+	if recording.Status != database.RecordingStatusRecording {
+		return "", ErrNotLive
+	}
+
+	return hlsFile(recording, filename)
+}
+
+// ClipChannel Enqueues the extraction of a range of the part which is currently being captured.
+// The offsets are seconds before the live edge, a duration takes precedence over the start offset.
+func ClipChannel(id database.ChannelID, start, end, duration uint) (*database.Job, error) {
+	activeRecLock.Lock()
+	part := parts[id]
+	activeRecLock.Unlock()
+	if part == nil || part.recording.StartedAt == nil {
+		return nil, ErrNotLive
+	}
+
+	args, err := clipRange(part.fileDuration(time.Now()), start, end, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	return part.recording.EnqueueClipJob(args)
+}
+
+// fileDuration The duration which the file of the part covers at the given time, the skipped start is not written.
+func (part *capturePart) fileDuration(now time.Time) time.Duration {
+	return now.Sub(*part.recording.StartedAt) - time.Duration(part.skip)*time.Second
+}
+
+// clipRange Converts the offsets relative to the live edge into a range from the start of the part.
+// The part only reaches back to its start, so the range is cut off there.
+func clipRange(elapsed time.Duration, start, end, duration uint) (*helpers.ClipArgs, error) {
+	if duration > 0 {
+		start = end + duration
+	}
+	if start <= end {
+		return nil, fmt.Errorf("the start offset (%ds) must lie before the end offset (%ds)", start, end)
+	}
+
+	edge := elapsed.Seconds()
+	to := edge - float64(end)
+	if to <= 0 {
+		return nil, fmt.Errorf("the range lies before the start of the recording (%.0fs ago)", edge)
+	}
+
+	return &helpers.ClipArgs{Start: max(edge-float64(start), 0), End: to}, nil
+}
+
+// ReadLivePlaylist Cuts the live window out of the event playlist of a capture.
+func ReadLivePlaylist(path string) (string, error) {
+	playlist, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return helpers.TrimHLSPlaylist(string(playlist), liveListSize), nil
+}
+
+func hlsFile(recording *database.Recording, filename string) (string, error) {
+	if filename != filepath.Base(filename) {
+		return "", fmt.Errorf("invalid filename '%s'", filename)
+	}
+	switch filepath.Ext(filename) {
+	case ".m3u8", ".ts":
+	default:
+		return "", fmt.Errorf("invalid filename '%s'", filename)
+	}
+
+	path := filepath.Join(recording.LiveFolder(), filename)
+	if _, err := os.Stat(path); err != nil {
+		if os.IsNotExist(err) {
+			return "", ErrNotLive
+		}
+		return "", err
+	}
+
+	return path, nil
+}
@@ -0,0 +1,58 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/helpers"
+)
+
+func TestClipRange(t *testing.T) {
+	elapsed := 10 * time.Minute
+
+	tests := []struct {
+		name                 string
+		start, end, duration uint
+		expected             helpers.ClipArgs
+	}{
+		{"duration", 0, 0, 120, helpers.ClipArgs{Start: 480, End: 600}},
+		{"duration before edge", 0, 60, 120, helpers.ClipArgs{Start: 420, End: 540}},
+		{"offsets", 300, 100, 0, helpers.ClipArgs{Start: 300, End: 500}},
+		{"beyond start", 0, 0, 3600, helpers.ClipArgs{Start: 0, End: 600}},
+	}
+
+	for _, test := range tests {
+		args, err := clipRange(elapsed, test.start, test.end, test.duration)
+		if err != nil {
+			t.Errorf("clipRange(%s) returned error: %s", test.name, err)
+			continue
+		}
+		if *args != test.expected {
+			t.Errorf("clipRange(%s) is %+v but should be %+v", test.name, *args, test.expected)
+		}
+	}
+
+	if _, err := clipRange(elapsed, 60, 120, 0); err == nil {
+		t.Error("clipRange with start after end should fail")
+	}
+	if _, err := clipRange(elapsed, 900, 700, 0); err == nil {
+		t.Error("clipRange before the start of the recording should fail")
+	}
+}
+
+func TestCapturePartFileDuration(t *testing.T) {
+	now := time.Now()
+	startedAt := now.Add(-10 * time.Minute)
+
+	// The first part of a capture skips the start of the stream, the file is shorter than the capture.
+	first := &capturePart{recording: &database.Recording{StartedAt: &startedAt}, skip: 30}
+	if duration := first.fileDuration(now); duration != 10*time.Minute-30*time.Second {
+		t.Errorf("file duration of the first part is %s", duration)
+	}
+
+	next := &capturePart{recording: &database.Recording{StartedAt: &startedAt}}
+	if duration := next.fileDuration(now); duration != 10*time.Minute {
+		t.Errorf("file duration of a following part is %s", duration)
+	}
+}
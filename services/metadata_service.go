@@ -0,0 +1,109 @@
+package services
+
+import (
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/network"
+	"github.com/srad/mediasink/resolvers"
+)
+
+// maxSearchResults Upper limit of the recordings returned by a search.
+const maxSearchResults = 200
+
+// streamMetadata The metadata of the broadcast, as reported by the resolver.
+func streamMetadata(stream *resolvers.Result) database.StreamMetadata {
+	return database.StreamMetadata{
+		Title:        strings.TrimSpace(stream.Title),
+		Description:  strings.TrimSpace(stream.Description),
+		Category:     strings.TrimSpace(stream.Category),
+		Uploader:     strings.TrimSpace(stream.Uploader),
+		ThumbnailURL: stream.ThumbnailURL,
+		OriginalID:   stream.OriginalID,
+	}
+}
+
+// applyStreamMetadata Copies the metadata into the recording before it is persisted.
+func applyStreamMetadata(recording *database.Recording, metadata database.StreamMetadata) {
+	recording.Title = metadata.Title
+	recording.Description = metadata.Description
+	recording.Category = metadata.Category
+	recording.Uploader = metadata.Uploader
+	recording.ThumbnailURL = metadata.ThumbnailURL
+	recording.OriginalID = metadata.OriginalID
+}
+
+// refreshesMetadata Only streams of sites have changing metadata, the stream is resolved again to read it.
+func refreshesMetadata(channel *database.Channel) bool {
+	if channel.Type != "" && channel.Type != database.ChannelTypeStream {
+		return false
+	}
+	return resolvers.Name(channel.Resolver) != resolvers.Direct
+}
+
+// refreshMetadata Resolves the stream of the channel again, while it is being captured.
+// Returns nil if the stream could not be resolved, the capture continues with the last known metadata.
+func refreshMetadata(channel database.Channel) *resolvers.Result {
+	applyRecordingTimer(&channel)
+
+	stream, err := resolveStream(&channel)
+	if err != nil {
+		log.Debugf("[Capture] Error refreshing metadata of %s: %v", channel.ChannelName, err)
+		return nil
+	}
+
+	return stream
+}
+
+// updateStreamMetadata Stores the refreshed metadata on the current part and records changes of the title or category.
+// Following parts of the capture inherit the metadata from the stream, its URL is kept.
+func updateStreamMetadata(channel *database.Channel, part *capturePart, stream, latest *resolvers.Result) {
+	metadata := streamMetadata(latest)
+	previous := streamMetadata(stream)
+	if metadata == previous {
+		return
+	}
+
+	stream.Title = latest.Title
+	stream.Description = latest.Description
+	stream.Category = latest.Category
+	stream.Uploader = latest.Uploader
+	stream.ThumbnailURL = latest.ThumbnailURL
+	stream.OriginalID = latest.OriginalID
+
+	if err := part.recording.UpdateMetadata(metadata); err != nil {
+		log.Errorf("[Capture] %v", err)
+	}
+
+	if metadata.Title != previous.Title || metadata.Category != previous.Category {
+		log.Infof("[Capture] %s changed the title to '%s' (%s)", channel.ChannelName, metadata.Title, metadata.Category)
+		if err := database.AddRecordingTitleChange(part.recording.RecordingID, metadata.Title, metadata.Category, time.Now()); err != nil {
+			log.Errorf("[Capture] Error adding title change of '%s': %v", part.outputPath, err)
+		}
+	}
+
+	streamInfoLock.Lock()
+	if info, ok := streamInfo[channel.ChannelID]; ok {
+		info.Title = metadata.Title
+		streamInfo[channel.ChannelID] = info
+	}
+	streamInfoLock.Unlock()
+
+	network.BroadCastClients(network.RecordingStatusEvent, part.recording)
+}
+
+// SearchRecordings Searches the title, description, category, uploader and former titles of the recordings.
+func SearchRecordings(query string, limit int) ([]*database.Recording, error) {
+	if limit <= 0 || limit > maxSearchResults {
+		limit = maxSearchResults
+	}
+
+	return database.SearchRecordings(query, limit)
+}
+
+// GetTitleChanges The titles of the broadcast during the capture of the recording.
+func GetTitleChanges(id database.RecordingID) ([]*database.RecordingTitleChange, error) {
+	return id.FindTitleChanges()
+}
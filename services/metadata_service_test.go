@@ -0,0 +1,33 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/resolvers"
+)
+
+func TestStreamMetadata(t *testing.T) {
+	metadata := streamMetadata(&resolvers.Result{URL: "https://example.com/live.m3u8", Title: " Speedrun ", Category: "Gaming", Uploader: "runner", OriginalID: "v123"})
+	if metadata.Title != "Speedrun" || metadata.Category != "Gaming" || metadata.Uploader != "runner" || metadata.OriginalID != "v123" {
+		t.Errorf("streamMetadata() is %+v", metadata)
+	}
+
+	recording := &database.Recording{}
+	applyStreamMetadata(recording, metadata)
+	if recording.Title != "Speedrun" || recording.Category != "Gaming" || recording.OriginalID != "v123" {
+		t.Errorf("recording is %+v", recording)
+	}
+}
+
+func TestRefreshesMetadata(t *testing.T) {
+	if !refreshesMetadata(&database.Channel{}) || !refreshesMetadata(&database.Channel{Type: database.ChannelTypeStream, Resolver: string(resolvers.Script)}) {
+		t.Error("streams of sites should refresh their metadata")
+	}
+	if refreshesMetadata(&database.Channel{Resolver: string(resolvers.Direct)}) {
+		t.Error("direct streams have no metadata")
+	}
+	if refreshesMetadata(&database.Channel{Type: database.ChannelTypeRadio}) || refreshesMetadata(&database.Channel{Type: database.ChannelTypeCamera}) {
+		t.Error("radio and camera channels should not refresh their metadata")
+	}
+}
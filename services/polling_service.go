@@ -0,0 +1,153 @@
+package services
+
+import (
+	"math/rand/v2"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+// channelPoll Tracks when a channel shall be queried next. Each consecutive offline result doubles the interval.
+type channelPoll struct {
+	failures    uint
+	nextCheckAt time.Time
+}
+
+// tokenBucket Limits the requests to a single host, shared by all channels on that host.
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+var (
+	polls       = make(map[database.ChannelID]*channelPoll)
+	pollWindows = make(map[database.ChannelID]string) // The recording window of the last check, see recordingWindow
+	hostTokens  = make(map[string]*tokenBucket)
+	pollLock    sync.Mutex
+)
+
+// pollBackoff Interval until the next check after the given number of consecutive offline results, without jitter.
+func pollBackoff(failures uint) time.Duration {
+	interval := breakBetweenCheckStreams
+	for i := uint(0); i < failures && interval < maxPollInterval; i++ {
+		interval *= 2
+	}
+	return min(interval, maxPollInterval)
+}
+
+// withJitter Spreads the interval randomly by pollJitter, so channels do not end up being checked in lockstep.
+func withJitter(interval time.Duration) time.Duration {
+	spread := float64(interval) * pollJitter
+	return interval + time.Duration(spread*(2*rand.Float64()-1))
+}
+
+// take Refills the bucket for the time passed and consumes a token, if one is available.
+func (bucket *tokenBucket) take(now time.Time) bool {
+	bucket.tokens = min(float64(hostRequestBurst), bucket.tokens+now.Sub(bucket.last).Seconds()/hostRequestInterval.Seconds())
+	bucket.last = now
+
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
+
+// isPollDue Checks if the backoff interval of the channel has passed.
+func isPollDue(id database.ChannelID, now time.Time) bool {
+	pollLock.Lock()
+	defer pollLock.Unlock()
+
+	poll, ok := polls[id]
+	return !ok || !now.Before(poll.nextCheckAt)
+}
+
+// takeHostToken Checks the rate limit of the host of the stream URL. URLs without host are not limited.
+func takeHostToken(streamURL string, now time.Time) bool {
+	u, err := url.Parse(streamURL)
+	if err != nil || u.Hostname() == "" {
+		return true
+	}
+
+	pollLock.Lock()
+	defer pollLock.Unlock()
+
+	bucket, ok := hostTokens[u.Hostname()]
+	if !ok {
+		bucket = &tokenBucket{tokens: float64(hostRequestBurst), last: now}
+		hostTokens[u.Hostname()] = bucket
+	}
+
+	return bucket.take(now)
+}
+
+// recordPoll Schedules the next check of the channel, depending on whether the stream was found online.
+func recordPoll(id database.ChannelID, online bool, now time.Time) {
+	pollLock.Lock()
+	defer pollLock.Unlock()
+
+	poll, ok := polls[id]
+	if !ok {
+		poll = &channelPoll{}
+		polls[id] = poll
+	}
+
+	if online {
+		poll.failures = 0
+	} else {
+		poll.failures++
+	}
+	poll.nextCheckAt = now.Add(withJitter(pollBackoff(poll.failures)))
+}
+
+// recordChannelPoll Like recordPoll, but cameras are expected to be online all the time and are retried after a fixed delay.
+func recordChannelPoll(channel *database.Channel, online bool, now time.Time) {
+	if channel.Type == database.ChannelTypeCamera {
+		schedulePoll(channel.ChannelID, cameraRetryDelay, now)
+		return
+	}
+	recordPoll(channel.ChannelID, online, now)
+}
+
+// schedulePoll Checks the channel again after the interval, i.e. feeds which are polled regularly.
+func schedulePoll(id database.ChannelID, interval time.Duration, now time.Time) {
+	pollLock.Lock()
+	defer pollLock.Unlock()
+
+	polls[id] = &channelPoll{nextCheckAt: now.Add(withJitter(interval))}
+}
+
+// enterWindow Forgets the backoff of the channel when its recording window changes, so that a timer or schedule
+// which opens is checked at once instead of at the end of the backoff.
+func enterWindow(id database.ChannelID, window string) {
+	pollLock.Lock()
+	defer pollLock.Unlock()
+
+	if previous, ok := pollWindows[id]; ok && previous == window {
+		return
+	}
+	pollWindows[id] = window
+	delete(polls, id)
+}
+
+// ResetPoll Checks the channel at the next round, i.e. once it has been paused, resumed, changed or deleted.
+func ResetPoll(id database.ChannelID) {
+	pollLock.Lock()
+	defer pollLock.Unlock()
+	delete(polls, id)
+	delete(pollWindows, id)
+}
+
+// NextCheckAt Time at which the channel will be queried next, nil if it is due.
+func NextCheckAt(id database.ChannelID) *time.Time {
+	pollLock.Lock()
+	defer pollLock.Unlock()
+
+	if poll, ok := polls[id]; ok && time.Now().Before(poll.nextCheckAt) {
+		next := poll.nextCheckAt
+		return &next
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestPollBackoff(t *testing.T) {
+	if backoff := pollBackoff(0); backoff != breakBetweenCheckStreams {
+		t.Errorf("pollBackoff(0) is %s but should be %s", backoff, breakBetweenCheckStreams)
+	}
+	if backoff := pollBackoff(3); backoff != 8*breakBetweenCheckStreams {
+		t.Errorf("pollBackoff(3) is %s but should be %s", backoff, 8*breakBetweenCheckStreams)
+	}
+	if backoff := pollBackoff(1000); backoff != maxPollInterval {
+		t.Errorf("pollBackoff(1000) is %s but should be capped at %s", backoff, maxPollInterval)
+	}
+}
+
+func TestWithJitter(t *testing.T) {
+	interval := time.Minute
+	spread := time.Duration(float64(interval) * pollJitter)
+
+	for i := 0; i < 100; i++ {
+		if jittered := withJitter(interval); jittered < interval-spread || jittered > interval+spread {
+			t.Fatalf("withJitter(%s) is %s, outside of ±%s", interval, jittered, spread)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	now := time.Now()
+	bucket := &tokenBucket{tokens: float64(hostRequestBurst), last: now}
+
+	for i := 0; i < hostRequestBurst; i++ {
+		if !bucket.take(now) {
+			t.Fatalf("take %d within burst was refused", i)
+		}
+	}
+	if bucket.take(now) {
+		t.Error("take beyond burst was allowed")
+	}
+	if !bucket.take(now.Add(hostRequestInterval)) {
+		t.Error("take after refill interval was refused")
+	}
+	if bucket.take(now.Add(hostRequestInterval)) {
+		t.Error("bucket refilled more than one token per interval")
+	}
+}
+
+func TestRecordChannelPollCamera(t *testing.T) {
+	camera := &database.Channel{ChannelID: 9001, Type: database.ChannelTypeCamera}
+	defer ResetPoll(camera.ChannelID)
+	now := time.Now()
+
+	// Offline cameras are not backed off, however often they fail.
+	for i := 0; i < 10; i++ {
+		recordChannelPoll(camera, false, now)
+	}
+	spread := time.Duration(float64(cameraRetryDelay) * pollJitter)
+	if next := NextCheckAt(camera.ChannelID); next == nil || next.After(time.Now().Add(cameraRetryDelay+spread)) {
+		t.Errorf("camera is checked at %v, later than the retry delay %s", next, cameraRetryDelay)
+	}
+}
+
+func TestEnterWindowResetsBackoff(t *testing.T) {
+	id := database.ChannelID(9002)
+	defer ResetPoll(id)
+	now := time.Now()
+
+	enterWindow(id, "always")
+	recordPoll(id, false, now)
+	recordPoll(id, false, now)
+
+	// The same window keeps the backoff.
+	enterWindow(id, "always")
+	if isPollDue(id, now) {
+		t.Error("backoff was reset within the same window")
+	}
+
+	// A timer opens.
+	enterWindow(id, "timer:1")
+	if !isPollDue(id, now) {
+		t.Error("channel is not checked at once when a timer opens")
+	}
+}
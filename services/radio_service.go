@@ -0,0 +1,221 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/resolvers"
+)
+
+// icyTrack A title of the ICY metadata and its offset within the recording.
+type icyTrack struct {
+	Offset time.Duration
+	Title  string
+}
+
+// icyReader Returns the audio data of an ICY stream and passes the titles of the interleaved metadata blocks to onTitle.
+// Every metaInt bytes of audio, the server sends one length byte followed by length*16 bytes of metadata.
+type icyReader struct {
+	reader    io.Reader
+	metaInt   int
+	audioLeft int
+	onTitle   func(title string)
+}
+
+func newICYReader(reader io.Reader, metaInt int, onTitle func(title string)) *icyReader {
+	return &icyReader{reader: reader, metaInt: metaInt, audioLeft: metaInt, onTitle: onTitle}
+}
+
+func (icy *icyReader) Read(p []byte) (int, error) {
+	if icy.metaInt <= 0 {
+		return icy.reader.Read(p)
+	}
+
+	if icy.audioLeft == 0 {
+		if err := icy.readMetadata(); err != nil {
+			return 0, err
+		}
+		icy.audioLeft = icy.metaInt
+	}
+
+	if len(p) > icy.audioLeft {
+		p = p[:icy.audioLeft]
+	}
+	n, err := icy.reader.Read(p)
+	icy.audioLeft -= n
+
+	return n, err
+}
+
+func (icy *icyReader) readMetadata() error {
+	var length [1]byte
+	if _, err := io.ReadFull(icy.reader, length[:]); err != nil {
+		return err
+	}
+	// Most blocks are empty, the metadata is only repeated when it changes.
+	if length[0] == 0 {
+		return nil
+	}
+
+	block := make([]byte, int(length[0])*16)
+	if _, err := io.ReadFull(icy.reader, block); err != nil {
+		return err
+	}
+
+	if title, ok := parseStreamTitle(string(bytes.TrimRight(block, "\x00"))); ok && icy.onTitle != nil {
+		icy.onTitle(title)
+	}
+
+	return nil
+}
+
+// parseStreamTitle Reads the title from metadata of the form "StreamTitle='Artist - Title';StreamUrl='...';".
+// The title itself might contain quotes, so it ends at the first "';".
+func parseStreamTitle(metadata string) (string, bool) {
+	const key = "StreamTitle='"
+
+	start := strings.Index(metadata, key)
+	if start < 0 {
+		return "", false
+	}
+	value := metadata[start+len(key):]
+
+	if end := strings.Index(value, "';"); end >= 0 {
+		value = value[:end]
+	} else {
+		value = strings.TrimSuffix(value, "'")
+	}
+
+	value = strings.TrimSpace(value)
+	return value, value != ""
+}
+
+// openICYStream Requests the stream with ICY metadata. The returned interval is the number of audio bytes
+// between two metadata blocks, 0 if the server does not send metadata.
+func openICYStream(ctx context.Context, stream *resolvers.Result) (*http.Response, int, error) {
+	request, err := http.NewRequestWithContext(ctx, http.MethodGet, stream.URL, nil)
+	if err != nil {
+		return nil, 0, err
+	}
+	for key, value := range stream.Headers {
+		request.Header.Set(key, value)
+	}
+	request.Header.Set("Icy-MetaData", "1")
+
+	client, err := newHTTPClient(stream.Proxy)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	response, err := client.Do(request)
+	if err != nil {
+		return nil, 0, err
+	}
+	if response.StatusCode != http.StatusOK {
+		_ = response.Body.Close()
+		return nil, 0, fmt.Errorf("radio stream responded with '%s'", response.Status)
+	}
+
+	metaInt := 0
+	if value := response.Header.Get("Icy-Metaint"); value != "" {
+		if metaInt, err = strconv.Atoi(value); err != nil || metaInt < 0 {
+			_ = response.Body.Close()
+			return nil, 0, fmt.Errorf("invalid icy-metaint '%s'", value)
+		}
+	}
+
+	return response, metaInt, nil
+}
+
+// cueSheet Lists the tracks of a radio recording, titles of the form "Artist - Title" are split into performer and title.
+func cueSheet(title string, filename database.RecordingFileName, tracks []icyTrack) string {
+	fileType := "WAVE"
+	if strings.EqualFold(filepath.Ext(filename.String()), ".mp3") {
+		fileType = "MP3"
+	}
+
+	var sheet strings.Builder
+	fmt.Fprintf(&sheet, "TITLE %s\n", cueQuote(title))
+	fmt.Fprintf(&sheet, "FILE %s %s\n", cueQuote(filename.String()), fileType)
+
+	for i, track := range tracks {
+		performer, name, found := strings.Cut(track.Title, " - ")
+		if !found {
+			performer, name = "", track.Title
+		}
+
+		// The index is given in minutes, seconds and CD frames (1/75s).
+		frames := track.Offset.Milliseconds() * 75 / 1000
+
+		fmt.Fprintf(&sheet, "  TRACK %02d AUDIO\n", i+1)
+		fmt.Fprintf(&sheet, "    TITLE %s\n", cueQuote(name))
+		if performer != "" {
+			fmt.Fprintf(&sheet, "    PERFORMER %s\n", cueQuote(performer))
+		}
+		fmt.Fprintf(&sheet, "    INDEX 01 %02d:%02d:%02d\n", frames/75/60, frames/75%60, frames%75)
+	}
+
+	return sheet.String()
+}
+
+func cueQuote(value string) string {
+	return `"` + strings.ReplaceAll(value, `"`, "'") + `"`
+}
+
+// writeCueSheet Writes the tracks next to the capture, the sheet refers to the file the capture is finalized to.
+func writeCueSheet(channel *database.Channel, recording *database.Recording, tracks []icyTrack) error {
+	format, err := channel.AudioFormat()
+	if err != nil {
+		return err
+	}
+
+	filename := recording.Filename.WithExtension(format.Extension)
+	path := channel.ChannelName.AbsoluteChannelFilePath(recording.Filename.WithExtension(database.CueSheetExtension))
+
+	return os.WriteFile(path, []byte(cueSheet(channel.DisplayName, filename, tracks)), 0644)
+}
+
+// CaptureRadio Captures an Icecast/Shoutcast stream like CaptureChannel, but reads the stream itself to parse the
+// ICY metadata. The audio data is passed on to ffmpeg and each title is written to the cue sheet of the recording.
+// If the channel splits tracks, every title change starts a new recording of the session, titled by the metadata.
+func CaptureRadio(id database.ChannelID, stream *resolvers.Result, skip uint) error {
+	if DiskLevel() == DiskLevelCritical {
+		return fmt.Errorf("CaptureRadio: not enough disk space to capture channel %d", id)
+	}
+
+	channel, err := database.GetChannelByID(id)
+	if err != nil {
+		return fmt.Errorf("CaptureRadio: failed to get channel %d: %w", id, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	response, metaInt, err := openICYStream(ctx, stream)
+	if err != nil {
+		return fmt.Errorf("CaptureRadio: %w", err)
+	}
+	defer response.Body.Close()
+
+	log.Infof("[Radio] Capturing %s (metadata interval: %d)", stream.URL, metaInt)
+
+	titles := make(chan string)
+	reader := newICYReader(response.Body, metaInt, func(title string) {
+		select {
+		case titles <- title:
+		case <-ctx.Done():
+		}
+	})
+
+	return capturePipe(ctx, cancel, channel, stream, reader, skip, titles)
+}
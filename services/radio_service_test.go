@@ -0,0 +1,100 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/resolvers"
+)
+
+// icyBlock Pads the metadata to a multiple of 16 bytes and prefixes its length.
+func icyBlock(metadata string) []byte {
+	length := (len(metadata) + 15) / 16
+	block := make([]byte, 1+length*16)
+	block[0] = byte(length)
+	copy(block[1:], metadata)
+	return block
+}
+
+func TestICYReader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Icy-MetaData") != "1" {
+			t.Error("request does not ask for ICY metadata")
+		}
+		w.Header().Set("icy-metaint", "8")
+		w.Header().Set("Content-Type", "audio/mpeg")
+
+		var body bytes.Buffer
+		body.WriteString("AAAAAAAA")
+		body.Write(icyBlock("StreamTitle='Guns N' Roses - Don't Cry';StreamUrl='';"))
+		body.WriteString("BBBBBBBB")
+		body.WriteByte(0)
+		body.WriteString("CCCCCCCC")
+		body.Write(icyBlock("StreamTitle='News';"))
+		body.WriteString("DDDD")
+		_, _ = w.Write(body.Bytes())
+	}))
+	defer server.Close()
+
+	response, metaInt, err := openICYStream(context.Background(), &resolvers.Result{URL: server.URL})
+	if err != nil {
+		t.Fatalf("openICYStream() returned error: %v", err)
+	}
+	defer response.Body.Close()
+	if metaInt != 8 {
+		t.Errorf("openICYStream() interval is %d", metaInt)
+	}
+
+	var titles []string
+	audio, err := io.ReadAll(newICYReader(response.Body, metaInt, func(title string) {
+		titles = append(titles, title)
+	}))
+	if err != nil {
+		t.Fatalf("reading the stream returned error: %v", err)
+	}
+
+	if string(audio) != "AAAAAAAABBBBBBBBCCCCCCCCDDDD" {
+		t.Errorf("audio data is %q", audio)
+	}
+	if len(titles) != 2 || titles[0] != "Guns N' Roses - Don't Cry" || titles[1] != "News" {
+		t.Errorf("titles are %q", titles)
+	}
+}
+
+func TestParseStreamTitle(t *testing.T) {
+	if title, ok := parseStreamTitle("StreamTitle='Artist - Title'"); !ok || title != "Artist - Title" {
+		t.Errorf("parseStreamTitle() is %q, %v", title, ok)
+	}
+	if _, ok := parseStreamTitle("StreamTitle='';"); ok {
+		t.Error("parseStreamTitle() with empty title should not return a title")
+	}
+	if _, ok := parseStreamTitle("StreamUrl='http://example.com';"); ok {
+		t.Error("parseStreamTitle() without title should not return a title")
+	}
+}
+
+func TestCueSheet(t *testing.T) {
+	tracks := []icyTrack{
+		{Offset: 0, Title: "Artist - First"},
+		{Offset: 3*time.Minute + 25*time.Second + 500*time.Millisecond, Title: `Jingle "Station"`},
+	}
+
+	expected := `TITLE "Radio"
+FILE "radio_2024_01_01_10_00_00.mp3" MP3
+  TRACK 01 AUDIO
+    TITLE "First"
+    PERFORMER "Artist"
+    INDEX 01 00:00:00
+  TRACK 02 AUDIO
+    TITLE "Jingle 'Station'"
+    INDEX 01 03:25:37
+`
+	if sheet := cueSheet("Radio", "radio_2024_01_01_10_00_00.mp3", tracks); sheet != expected {
+		t.Errorf("cueSheet() is\n%s", sheet)
+	}
+}
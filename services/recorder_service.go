@@ -16,6 +16,32 @@ const (
 	breakBetweenCheckStreams = 10 * time.Second // Interval for the main stream checking loop
 	captureThumbInterval     = 30 * time.Second // Interval for thumbnail worker (implementation not shown)
 	maxConcurrentChecks      = 5                // Max number of concurrent stream checks/start attempts
+	segmentCheckInterval     = 5 * time.Second  // Interval in which a running capture is checked against its segment policy
+	rolloverTimeout          = 30 * time.Second // Max time the next segment may take to write data before the rollover is aborted
+	defaultStallTimeout      = 60 * time.Second // Stall timeout of channels which have none
+	rolloverRetryDelay       = 30 * time.Second // Delay before a failed rollover is attempted again, doubled with every failure
+	maxRolloverDelay         = 10 * time.Minute // Upper limit of the delay between failed rollovers
+	resolveTimeout           = 30 * time.Second // Max time a resolver may take to query the stream url
+	retentionInterval        = 15 * time.Minute // Interval in which the retention policies are enforced
+	diskCheckInterval        = 30 * time.Second // Interval in which the disk watchdog checks the free space
+	maxPollInterval          = 30 * time.Minute // Upper limit of the backoff for channels which are offline
+	pollJitter               = 0.2              // Random spread of the poll interval, as fraction
+	hostRequestInterval      = 2 * time.Second  // One stream query per host is allowed within this interval ...
+	hostRequestBurst         = 5                // ... plus this many at once
+	liveSegmentDuration      = 4                // Seconds per segment of the HLS playlist of a capture
+	liveListSize             = 6                // Number of segments in the live window of the HLS playlist
+	progressEventInterval    = 2 * time.Second  // Min. interval between two progress events of a capture
+	stderrLines              = 100              // Number of stderr lines of a capture kept for the process list
+	snapshotAudioSeconds     = 10               // Seconds of an audio-only stream rendered as waveform for the live snapshot
+	ingestSyncInterval       = 10 * time.Second // Interval in which the listeners of ingest channels are started and stopped
+	ingestRetryDelay         = 2 * time.Second  // Delay before an ingest channel listens again for a publisher
+	cameraSegmentMinutes     = 10               // Segment length of cameras without a segment duration
+	cameraRetryDelay         = 10 * time.Second // Fixed delay before an offline camera is checked again, cameras are not backed off
+	feedPollInterval         = 30 * time.Minute // Interval in which the feeds of feed channels are checked for new episodes
+	downloadTimeout          = 2 * time.Hour    // Max time the download of a feed episode or archive video may take
+	archivePollInterval      = 6 * time.Hour    // Interval in which the playlists of archive channels are checked for new videos
+	playlistTimeout          = 5 * time.Minute  // Max time yt-dlp may take to list a playlist, long uploads pages are paged
+	metadataRefreshInterval  = 10 * time.Minute // Interval in which the title and metadata of a stream are read again while it is captured
 )
 
 // recorderControlMessage defines the type for control messages sent to the stream worker.
@@ -51,6 +77,7 @@ func StartRecorder() {
 	go startStreamWorker(ctx, controlChannel)
 	// Assuming startThumbnailWorker is also context-aware and part of the recorder system
 	go startThumbnailWorker(ctx) // This was in the original StartRecorder
+	go startIngestWorker(ctx)
 
 	recorderActive = true
 	log.Infoln("[Recorder] Started recording workers.")
@@ -164,19 +191,76 @@ func checkStreams(ctx context.Context) {
 				return // Skip this channel
 			}
 
+			// Ingest channels are not polled, the ingest worker waits for their publishers.
+			if currentChannelState.Type == database.ChannelTypeIngest {
+				return
+			}
+
+			window, errSchedule := recordingWindow(currentChannelState.ChannelID, time.Now())
+			if errSchedule != nil {
+				log.Errorf("[checkStreams] Error checking schedule of channel %s (ID: %d): %v", currentChannelState.ChannelName, currentChannelState.ChannelID, errSchedule)
+				return
+			}
+			enterWindow(currentChannelState.ChannelID, window)
+
+			// The URL of a timer is only captured within its window, even if the channel may be recorded afterwards.
+			if timer := capturedTimer(currentChannelState.ChannelID); timer != 0 && window != timerWindow(timer) && IsRecordingStream(currentChannelState.ChannelID) && !IsTerminating(currentChannelState.ChannelID) {
+				log.Infof("[checkStreams] Timer %d of channel %s (ID: %d) ended, stopping capture.", timer, currentChannelState.ChannelName, currentChannelState.ChannelID)
+				if errTerminate := TerminateProcess(currentChannelState.ChannelID); errTerminate != nil {
+					log.Errorf("[checkStreams] Error stopping channel %s (ID: %d): %v", currentChannelState.ChannelName, currentChannelState.ChannelID, errTerminate)
+				}
+				return
+			}
+			if window == "" {
+				// The recording window ended while the channel was being captured.
+				if IsRecordingStream(currentChannelState.ChannelID) && !IsTerminating(currentChannelState.ChannelID) {
+					log.Infof("[checkStreams] Channel %s (ID: %d) is outside of its schedule, stopping capture.", currentChannelState.ChannelName, currentChannelState.ChannelID)
+					if errTerminate := TerminateProcess(currentChannelState.ChannelID); errTerminate != nil {
+						log.Errorf("[checkStreams] Error stopping channel %s (ID: %d): %v", currentChannelState.ChannelName, currentChannelState.ChannelID, errTerminate)
+					}
+				}
+				log.Debugf("[checkStreams] Channel %s (ID: %d) is outside of its schedule. Skipping.", currentChannelState.ChannelName, currentChannelState.ChannelID)
+				return
+			}
+
 			if IsRecordingStream(currentChannelState.ChannelID) {
 				log.Debugf("[checkStreams] Channel %s (ID: %d) is already recording. Skipping.", currentChannelState.ChannelName, currentChannelState.ChannelID)
 				return
 			}
+			if DiskLevel() == DiskLevelCritical {
+				log.Debugf("[checkStreams] Disk space is critical, not starting channel %s (ID: %d).", currentChannelState.ChannelName, currentChannelState.ChannelID)
+				return
+			}
 			if currentChannelState.IsPaused {
 				log.Debugf("[checkStreams] Channel %s (ID: %d) is marked as paused in database. Skipping.", currentChannelState.ChannelName, currentChannelState.ChannelID)
 				return
 			}
 
+			now := time.Now()
+			if !isPollDue(currentChannelState.ChannelID, now) {
+				log.Debugf("[checkStreams] Channel %s (ID: %d) is backing off. Skipping.", currentChannelState.ChannelName, currentChannelState.ChannelID)
+				return
+			}
+			if !takeHostToken(currentChannelState.URL, now) {
+				log.Debugf("[checkStreams] Rate limit of host reached for channel %s (ID: %d). Skipping.", currentChannelState.ChannelName, currentChannelState.ChannelID)
+				return
+			}
+
+			// Feeds and archives are downloaded, not captured.
+			if currentChannelState.Type == database.ChannelTypeFeed {
+				pollFeed(currentChannelState)
+				return
+			}
+			if currentChannelState.Type == database.ChannelTypeArchive {
+				pollArchive(currentChannelState)
+				return
+			}
+
 			log.Infof("[checkStreams] Attempting to start stream for channel: %s (ID: %d)", currentChannelState.ChannelName, currentChannelState.ChannelID)
 
 			// Preserving the original logic for handling Start() return values and broadcasting.
-			started, startErr := Start(currentChannelState.ChannelID)
+			result, startErr := startChannel(currentChannelState.ChannelID)
+			started := result == startStarted
 
 			if started && startErr != nil {
 				log.Warnf("[checkStreams] Attempted to start channel %s (ID: %d), but received an error. Broadcasting offline. Error: %v", currentChannelState.ChannelName, currentChannelState.ChannelID, startErr)
@@ -191,6 +275,9 @@ func checkStreams(ctx context.Context) {
 			}
 			// If !started, the original code did not broadcast anything from this block.
 
+			// A skipped broadcast is online, it is checked again in the regular interval in case it changes.
+			recordChannelPoll(currentChannelState, (started && startErr == nil) || result == startSkipped, time.Now())
+
 			// Sleep after each attempt, as in the original sequential loop.
 			// This might be for rate-limiting the Start() calls.
 			time.Sleep(streamCheckBreak)
@@ -205,7 +292,7 @@ func checkStreams(ctx context.Context) {
 // GeneratePosters generates preview posters for all existing recordings.
 func GeneratePosters() error {
 	log.Infoln("[GeneratePosters] Starting to update poster images for all recordings.")
-	recordings, err := database.RecordingsList()
+	recordings, err := database.RecordingsListByStatus(database.RecordingStatusReady)
 	if err != nil {
 		log.Errorf("[GeneratePosters] Error fetching recordings list: %v", err)
 		return err
@@ -3,6 +3,7 @@ package services
 import (
 	log "github.com/sirupsen/logrus"
 	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/network"
 )
 
 var (
@@ -11,7 +12,7 @@ var (
 
 func UpdateVideoInfo() error {
 	log.Infoln("[Recorder] Updating all recordings info")
-	recordings, err := database.RecordingsList()
+	recordings, err := database.RecordingsListByStatus(database.RecordingStatusReady)
 	if err != nil {
 		log.Errorln(err)
 		return err
@@ -43,3 +44,12 @@ func UpdateVideoInfo() error {
 func IsUpdatingRecordings() bool {
 	return isUpdating
 }
+
+// setRecordingStatus Persists the status transition of a recording and notifies the clients.
+func setRecordingStatus(recording *database.Recording, status database.RecordingStatus) {
+	if err := recording.UpdateStatus(status); err != nil {
+		log.Errorf("[Recording] %s", err)
+		return
+	}
+	network.BroadCastClients(network.RecordingStatusEvent, recording)
+}
@@ -0,0 +1,216 @@
+package services
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/network"
+)
+
+var (
+	cancelRetention context.CancelFunc
+)
+
+// RetentionPolicy Limits for the recordings of a channel or all channels, 0 disables a rule.
+// Bookmarked recordings are exempt, they neither count towards the limits nor are they deleted.
+type RetentionPolicy struct {
+	Count uint
+	Size  uint // Gigabytes
+	Days  uint
+}
+
+func (policy RetentionPolicy) isEmpty() bool {
+	return policy.Count == 0 && policy.Size == 0 && policy.Days == 0
+}
+
+// planRetention Returns the recordings which violate the policy, the newest recordings are kept first.
+func planRetention(recordings []*database.Recording, policy RetentionPolicy, now time.Time) []*database.Recording {
+	if policy.isEmpty() {
+		return nil
+	}
+
+	candidates := make([]*database.Recording, 0, len(recordings))
+	for _, recording := range recordings {
+		if !recording.Bookmark {
+			candidates = append(candidates, recording)
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].CreatedAt.After(candidates[j].CreatedAt)
+	})
+
+	maxSize := uint64(policy.Size) * 1024 * 1024 * 1024
+	minCreatedAt := now.Add(-time.Duration(policy.Days) * 24 * time.Hour)
+
+	var remove []*database.Recording
+	var keptSize uint64
+	kept := uint(0)
+
+	for _, recording := range candidates {
+		expired := policy.Days > 0 && recording.CreatedAt.Before(minCreatedAt)
+		exceedsCount := policy.Count > 0 && kept >= policy.Count
+		exceedsSize := policy.Size > 0 && keptSize+recording.Size > maxSize
+
+		if expired || exceedsCount || exceedsSize {
+			remove = append(remove, recording)
+			continue
+		}
+
+		kept++
+		keptSize += recording.Size
+	}
+
+	return remove
+}
+
+// channelRetentionPolicy The ring quota of a camera is a size limit, the smaller of both limits applies.
+func channelRetentionPolicy(channel *database.Channel) RetentionPolicy {
+	policy := RetentionPolicy{Count: channel.RetentionCount, Size: channel.RetentionSize, Days: channel.RetentionDays}
+	if channel.Type == database.ChannelTypeCamera && channel.RingQuota > 0 && (policy.Size == 0 || channel.RingQuota < policy.Size) {
+		policy.Size = channel.RingQuota
+	}
+	return policy
+}
+
+// enforceRingBuffer Deletes the oldest segments of a camera which exceed its policy. Unlike the retention
+// policies, which are checked periodically, it is enforced whenever a segment has been finalized.
+func enforceRingBuffer(id database.ChannelID) error {
+	channel, err := database.GetChannelByID(id)
+	if err != nil {
+		return err
+	}
+	if channel.Type != database.ChannelTypeCamera || channel.RingQuota == 0 {
+		return nil
+	}
+
+	recordings, err := id.FindRecordings(database.RecordingStatusReady)
+	if err != nil {
+		return err
+	}
+
+	for _, recording := range planRetention(recordings, channelRetentionPolicy(channel), time.Now()) {
+		log.Infof("[Retention] Ring buffer of %s is full, deleting %s", channel.ChannelName, recording.Filename)
+		if err := recording.DestroyRecording(); err != nil {
+			log.Errorf("[Retention] Error deleting recording %s/%s: %s", recording.ChannelName, recording.Filename, err)
+			continue
+		}
+		network.BroadCastClients(network.RecordingDeleteEvent, recording)
+	}
+
+	return nil
+}
+
+func globalRetentionPolicy() (RetentionPolicy, error) {
+	count, err := database.GetIntValue(database.RetentionCount)
+	if err != nil {
+		return RetentionPolicy{}, err
+	}
+	size, err := database.GetIntValue(database.RetentionSize)
+	if err != nil {
+		return RetentionPolicy{}, err
+	}
+	days, err := database.GetIntValue(database.RetentionDays)
+	if err != nil {
+		return RetentionPolicy{}, err
+	}
+
+	return RetentionPolicy{Count: uint(max(count, 0)), Size: uint(max(size, 0)), Days: uint(max(days, 0))}, nil
+}
+
+// PlanRetention Lists all recordings which violate the retention policy of their channel or the global policy.
+// Only finished recordings are considered.
+func PlanRetention() ([]*database.Recording, error) {
+	recordings, err := database.RecordingsListByStatus(database.RecordingStatusReady)
+	if err != nil {
+		return nil, err
+	}
+
+	channels, err := database.ChannelList()
+	if err != nil {
+		return nil, err
+	}
+
+	byChannel := make(map[database.ChannelID][]*database.Recording)
+	for _, recording := range recordings {
+		byChannel[recording.ChannelID] = append(byChannel[recording.ChannelID], recording)
+	}
+
+	now := time.Now()
+	removed := make(map[database.RecordingID]bool)
+	var remove []*database.Recording
+
+	for _, channel := range channels {
+		for _, recording := range planRetention(byChannel[channel.ChannelID], channelRetentionPolicy(channel), now) {
+			removed[recording.RecordingID] = true
+			remove = append(remove, recording)
+		}
+	}
+
+	global, err := globalRetentionPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	remaining := make([]*database.Recording, 0, len(recordings))
+	for _, recording := range recordings {
+		if !removed[recording.RecordingID] {
+			remaining = append(remaining, recording)
+		}
+	}
+	remove = append(remove, planRetention(remaining, global, now)...)
+
+	return remove, nil
+}
+
+// EnforceRetention Deletes all recordings which violate a retention policy.
+func EnforceRetention() error {
+	recordings, err := PlanRetention()
+	if err != nil {
+		return err
+	}
+
+	for _, recording := range recordings {
+		log.Infof("[Retention] Deleting recording %s/%s", recording.ChannelName, recording.Filename)
+		if err := recording.DestroyRecording(); err != nil {
+			log.Errorf("[Retention] Error deleting recording %s/%s: %s", recording.ChannelName, recording.Filename, err)
+			continue
+		}
+		network.BroadCastClients(network.RecordingDeleteEvent, recording)
+	}
+
+	return nil
+}
+
+func StartRetention() {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancelRetention = cancel
+	go retentionWorker(ctx)
+}
+
+func StopRetention() {
+	if cancelRetention != nil {
+		cancelRetention()
+	}
+}
+
+func retentionWorker(ctx context.Context) {
+	log.Infoln("[Retention] Worker started.")
+	defer log.Infoln("[Retention] Worker stopped.")
+
+	ticker := time.NewTicker(retentionInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := EnforceRetention(); err != nil {
+				log.Errorf("[Retention] Error: %s", err)
+			}
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+const gigabyte = 1024 * 1024 * 1024
+
+func retentionFixture(now time.Time) []*database.Recording {
+	return []*database.Recording{
+		{RecordingID: 1, CreatedAt: now.Add(-10 * 24 * time.Hour), Size: gigabyte},
+		{RecordingID: 2, CreatedAt: now.Add(-5 * 24 * time.Hour), Size: gigabyte, Bookmark: true},
+		{RecordingID: 3, CreatedAt: now.Add(-3 * 24 * time.Hour), Size: gigabyte},
+		{RecordingID: 4, CreatedAt: now.Add(-2 * 24 * time.Hour), Size: gigabyte},
+		{RecordingID: 5, CreatedAt: now.Add(-1 * time.Hour), Size: gigabyte},
+	}
+}
+
+func recordingIDs(recordings []*database.Recording) []database.RecordingID {
+	ids := make([]database.RecordingID, len(recordings))
+	for i, recording := range recordings {
+		ids[i] = recording.RecordingID
+	}
+	return ids
+}
+
+func equalIDs(a []database.RecordingID, b ...database.RecordingID) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestPlanRetention(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name     string
+		policy   RetentionPolicy
+		expected []database.RecordingID
+	}{
+		{"empty", RetentionPolicy{}, nil},
+		{"count", RetentionPolicy{Count: 2}, []database.RecordingID{3, 1}},
+		{"size", RetentionPolicy{Size: 3}, []database.RecordingID{1}},
+		{"days", RetentionPolicy{Days: 4}, []database.RecordingID{1}},
+		{"combined", RetentionPolicy{Count: 3, Size: 1, Days: 30}, []database.RecordingID{4, 3, 1}},
+	}
+
+	for _, test := range tests {
+		ids := recordingIDs(planRetention(retentionFixture(now), test.policy, now))
+		if !equalIDs(ids, test.expected...) {
+			t.Errorf("planRetention(%s) is %v but should be %v", test.name, ids, test.expected)
+		}
+	}
+}
+
+func TestChannelRetentionPolicy(t *testing.T) {
+	tests := []struct {
+		name     string
+		channel  database.Channel
+		expected RetentionPolicy
+	}{
+		{"stream ignores ring quota", database.Channel{RetentionSize: 5, RingQuota: 2}, RetentionPolicy{Size: 5}},
+		{"camera ring quota", database.Channel{Type: database.ChannelTypeCamera, RetentionDays: 7, RingQuota: 2}, RetentionPolicy{Size: 2, Days: 7}},
+		{"smaller retention size", database.Channel{Type: database.ChannelTypeCamera, RetentionSize: 1, RingQuota: 2}, RetentionPolicy{Size: 1}},
+	}
+
+	for _, test := range tests {
+		if policy := channelRetentionPolicy(&test.channel); policy != test.expected {
+			t.Errorf("channelRetentionPolicy(%s) is %+v but should be %+v", test.name, policy, test.expected)
+		}
+	}
+}
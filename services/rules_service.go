@@ -0,0 +1,97 @@
+package services
+
+import (
+	"fmt"
+	"regexp"
+	"sync"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/resolvers"
+)
+
+var (
+	// A skipped broadcast is polled again and again, each reason is only logged once.
+	skipReasons     = make(map[database.ChannelID]string)
+	skipReasonsLock sync.Mutex
+)
+
+// matchRule Reports whether the pattern matches the value, an empty pattern matches everything.
+// The patterns have been validated when the channel was saved, an invalid one matches nothing.
+func matchRule(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+	matched, err := regexp.MatchString(pattern, value)
+	return err == nil && matched
+}
+
+// evaluateRules Checks the broadcast against the capture rules of the channel.
+// Returns the reason if the broadcast must not be captured, an empty reason accepts it.
+// The resolution is only checked if the resolver reports it.
+func evaluateRules(rules database.CaptureRules, stream *resolvers.Result) string {
+	if rules.ExcludeTitle != "" && matchRule(rules.ExcludeTitle, stream.Title) {
+		return fmt.Sprintf("title '%s' matches excluded pattern '%s'", stream.Title, rules.ExcludeTitle)
+	}
+	if rules.ExcludeCategory != "" && matchRule(rules.ExcludeCategory, stream.Category) {
+		return fmt.Sprintf("category '%s' matches excluded pattern '%s'", stream.Category, rules.ExcludeCategory)
+	}
+	if !matchRule(rules.IncludeTitle, stream.Title) {
+		return fmt.Sprintf("title '%s' does not match pattern '%s'", stream.Title, rules.IncludeTitle)
+	}
+	if !matchRule(rules.IncludeCategory, stream.Category) {
+		return fmt.Sprintf("category '%s' does not match pattern '%s'", stream.Category, rules.IncludeCategory)
+	}
+	if rules.MinResolution > 0 && stream.Height > 0 && stream.Height < rules.MinResolution {
+		return fmt.Sprintf("resolution %dp is below %dp", stream.Height, rules.MinResolution)
+	}
+	return ""
+}
+
+// skipBroadcast Logs why the broadcast is not captured, unless it has been skipped for the same reason before.
+func skipBroadcast(channel *database.Channel, reason string) {
+	skipReasonsLock.Lock()
+	defer skipReasonsLock.Unlock()
+
+	if skipReasons[channel.ChannelID] == reason {
+		return
+	}
+	skipReasons[channel.ChannelID] = reason
+
+	log.Infof("[Rules] Skipping broadcast of %s: %s", channel.ChannelName, reason)
+	addChannelSessionEvent(channel.ChannelID, nil, database.ChannelSessionEventSkipped, reason)
+}
+
+// acceptBroadcast Resets the skip reason, so that the next skip is logged again.
+func acceptBroadcast(id database.ChannelID) {
+	skipReasonsLock.Lock()
+	defer skipReasonsLock.Unlock()
+
+	delete(skipReasons, id)
+}
+
+// enforceRules Stops the capture once the refreshed broadcast is rejected by the rules, if the channel asks for it.
+func enforceRules(channel *database.Channel, part *capturePart, stream *resolvers.Result) {
+	rules := channel.CaptureRules
+	if !rules.StopOnExclude {
+		return
+	}
+
+	reason := evaluateRules(rules, stream)
+	if reason == "" {
+		return
+	}
+
+	log.Infof("[Rules] Stopping capture of %s: %s", channel.ChannelName, reason)
+	addChannelSessionEvent(channel.ChannelID, part.recording.SessionID, database.ChannelSessionEventStopped, reason)
+
+	if err := TerminateProcess(channel.ChannelID); err != nil {
+		log.Errorf("[Rules] Error stopping capture of %s: %v", channel.ChannelName, err)
+		return
+	}
+
+	// The broadcast is skipped from now on, the stop has been logged instead.
+	skipReasonsLock.Lock()
+	skipReasons[channel.ChannelID] = reason
+	skipReasonsLock.Unlock()
+}
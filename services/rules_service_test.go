@@ -0,0 +1,55 @@
+package services
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/srad/mediasink/database"
+	"github.com/srad/mediasink/resolvers"
+)
+
+func TestEvaluateRules(t *testing.T) {
+	stream := &resolvers.Result{Title: "Speedrun: Any% World Record", Category: "Retro", Height: 720}
+
+	if reason := evaluateRules(database.CaptureRules{}, stream); reason != "" {
+		t.Errorf("empty rules rejected the broadcast: %s", reason)
+	}
+
+	tests := []struct {
+		rules    database.CaptureRules
+		rejected string
+	}{
+		{database.CaptureRules{IncludeTitle: "(?i)speedrun"}, ""},
+		{database.CaptureRules{IncludeTitle: "(?i)podcast"}, "does not match"},
+		{database.CaptureRules{ExcludeTitle: "Record$"}, "excluded pattern"},
+		{database.CaptureRules{IncludeCategory: "^Retro$", ExcludeCategory: "Chatting"}, ""},
+		{database.CaptureRules{IncludeCategory: "^Just Chatting$"}, "category"},
+		// Exclusion wins over inclusion.
+		{database.CaptureRules{IncludeTitle: "Speedrun", ExcludeCategory: "Retro"}, "excluded pattern"},
+		{database.CaptureRules{MinResolution: 720}, ""},
+		{database.CaptureRules{MinResolution: 1080}, "below 1080p"},
+	}
+	for _, test := range tests {
+		reason := evaluateRules(test.rules, stream)
+		if test.rejected == "" && reason != "" {
+			t.Errorf("%+v rejected the broadcast: %s", test.rules, reason)
+		}
+		if test.rejected != "" && !strings.Contains(reason, test.rejected) {
+			t.Errorf("%+v returned reason %q, expected %q", test.rules, reason, test.rejected)
+		}
+	}
+
+	// Resolvers which don't report the resolution pass the minimum.
+	if reason := evaluateRules(database.CaptureRules{MinResolution: 1080}, &resolvers.Result{}); reason != "" {
+		t.Errorf("unknown resolution rejected the broadcast: %s", reason)
+	}
+}
+
+func TestCaptureRulesIsValid(t *testing.T) {
+	if err := (database.CaptureRules{IncludeTitle: "(?i)show", ExcludeCategory: "^(IRL|Chatting)$"}).IsValid(); err != nil {
+		t.Errorf("IsValid() returned error: %v", err)
+	}
+	if err := (database.CaptureRules{ExcludeTitle: "(unclosed"}).IsValid(); err == nil {
+		t.Error("IsValid() should reject an invalid pattern")
+	}
+}
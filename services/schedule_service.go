@@ -0,0 +1,110 @@
+package services
+
+import (
+	"fmt"
+	"time"
+	_ "time/tzdata" // Timezones of schedules must also resolve on systems without zoneinfo.
+
+	"github.com/srad/mediasink/database"
+)
+
+const scheduleTimeLayout = "15:04"
+
+// ValidateSchedule Checks the time window and timezone of the schedule.
+func ValidateSchedule(schedule *database.Schedule) error {
+	if _, err := time.Parse(scheduleTimeLayout, schedule.StartTime); err != nil {
+		return fmt.Errorf("invalid start time '%s', expected HH:MM", schedule.StartTime)
+	}
+	if _, err := time.Parse(scheduleTimeLayout, schedule.EndTime); err != nil {
+		return fmt.Errorf("invalid end time '%s', expected HH:MM", schedule.EndTime)
+	}
+	if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+		return fmt.Errorf("invalid timezone '%s': %w", schedule.Timezone, err)
+	}
+	return schedule.Weekdays.IsValid()
+}
+
+// scheduleContains Checks if the time lies within the window of the schedule.
+// A window which ends before it starts spans midnight and belongs to the weekday it started on.
+// Equal start and end times cover the whole day.
+func scheduleContains(schedule *database.Schedule, now time.Time) (bool, error) {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return false, err
+	}
+	start, err := time.Parse(scheduleTimeLayout, schedule.StartTime)
+	if err != nil {
+		return false, err
+	}
+	end, err := time.Parse(scheduleTimeLayout, schedule.EndTime)
+	if err != nil {
+		return false, err
+	}
+
+	local := now.In(location)
+	minute := local.Hour()*60 + local.Minute()
+	startMinute := start.Hour()*60 + start.Minute()
+	endMinute := end.Hour()*60 + end.Minute()
+	today := local.Weekday()
+	yesterday := (today + 6) % 7
+
+	switch {
+	case startMinute < endMinute:
+		return schedule.Weekdays.Contains(today) && minute >= startMinute && minute < endMinute, nil
+	case startMinute > endMinute:
+		return (schedule.Weekdays.Contains(today) && minute >= startMinute) || (schedule.Weekdays.Contains(yesterday) && minute < endMinute), nil
+	default:
+		return schedule.Weekdays.Contains(today), nil
+	}
+}
+
+// isScheduled Checks if the channel may be polled and recorded at the given time.
+// An active timer always allows the recording, otherwise the channel must be within one of its
+// enabled schedules, if it has any.
+func isScheduled(channelID database.ChannelID, now time.Time) (bool, error) {
+	window, err := recordingWindow(channelID, now)
+	return window != "", err
+}
+
+func timerWindow(id database.TimerID) string {
+	return fmt.Sprintf("timer:%d", id)
+}
+
+// recordingWindow Identifies the window in which the channel may be recorded at the given time, see isScheduled.
+// That is the active timer, the schedule which contains the time, or "always" for channels without schedules.
+// Empty outside all windows.
+func recordingWindow(channelID database.ChannelID, now time.Time) (string, error) {
+	timer, err := channelID.FindActiveRecordingTimer(now)
+	if err != nil {
+		return "", err
+	}
+	if timer != nil {
+		return timerWindow(timer.TimerID), nil
+	}
+
+	schedules, err := channelID.FindSchedules()
+	if err != nil {
+		return "", err
+	}
+
+	hasSchedule := false
+	for _, schedule := range schedules {
+		if !schedule.Enabled {
+			continue
+		}
+		hasSchedule = true
+
+		contains, err := scheduleContains(schedule, now)
+		if err != nil {
+			return "", fmt.Errorf("schedule %d: %w", schedule.ScheduleID, err)
+		}
+		if contains {
+			return fmt.Sprintf("schedule:%d", schedule.ScheduleID), nil
+		}
+	}
+
+	if hasSchedule {
+		return "", nil
+	}
+	return "always", nil
+}
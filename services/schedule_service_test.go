@@ -0,0 +1,81 @@
+package services
+
+import (
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestScheduleContains(t *testing.T) {
+	schedule := &database.Schedule{
+		Weekdays:  database.Weekdays{time.Monday, time.Tuesday},
+		StartTime: "18:00",
+		EndTime:   "23:00",
+		Timezone:  "Europe/Berlin",
+	}
+
+	berlin, _ := time.LoadLocation("Europe/Berlin")
+
+	tests := []struct {
+		now      time.Time
+		expected bool
+	}{
+		{time.Date(2024, 6, 3, 18, 0, 0, 0, berlin), true},    // Monday
+		{time.Date(2024, 6, 3, 22, 59, 0, 0, berlin), true},   // Monday
+		{time.Date(2024, 6, 3, 23, 0, 0, 0, berlin), false},   // Monday, end is exclusive
+		{time.Date(2024, 6, 5, 19, 0, 0, 0, berlin), false},   // Wednesday
+		{time.Date(2024, 6, 3, 16, 30, 0, 0, time.UTC), true}, // Monday 18:30 in Berlin
+	}
+
+	for _, test := range tests {
+		contains, err := scheduleContains(schedule, test.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contains != test.expected {
+			t.Errorf("scheduleContains(%s) is %v but should be %v", test.now, contains, test.expected)
+		}
+	}
+}
+
+func TestScheduleContainsMidnight(t *testing.T) {
+	schedule := &database.Schedule{
+		Weekdays:  database.Weekdays{time.Friday},
+		StartTime: "22:00",
+		EndTime:   "02:00",
+		Timezone:  "UTC",
+	}
+
+	tests := []struct {
+		now      time.Time
+		expected bool
+	}{
+		{time.Date(2024, 6, 7, 23, 0, 0, 0, time.UTC), true},  // Friday
+		{time.Date(2024, 6, 8, 1, 0, 0, 0, time.UTC), true},   // Saturday, window started on Friday
+		{time.Date(2024, 6, 8, 23, 0, 0, 0, time.UTC), false}, // Saturday
+		{time.Date(2024, 6, 7, 1, 0, 0, 0, time.UTC), false},  // Friday, window of Thursday
+	}
+
+	for _, test := range tests {
+		contains, err := scheduleContains(schedule, test.now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if contains != test.expected {
+			t.Errorf("scheduleContains(%s) is %v but should be %v", test.now, contains, test.expected)
+		}
+	}
+}
+
+func TestValidateSchedule(t *testing.T) {
+	if err := ValidateSchedule(&database.Schedule{StartTime: "18:00", EndTime: "23:00", Timezone: "Mars/Olympus"}); err == nil {
+		t.Error("ValidateSchedule() should reject unknown timezones")
+	}
+	if err := ValidateSchedule(&database.Schedule{StartTime: "25:00", EndTime: "23:00"}); err == nil {
+		t.Error("ValidateSchedule() should reject invalid times")
+	}
+	if err := ValidateSchedule(&database.Schedule{StartTime: "18:00", EndTime: "23:00", Weekdays: database.Weekdays{7}}); err == nil {
+		t.Error("ValidateSchedule() should reject invalid weekdays")
+	}
+}
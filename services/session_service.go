@@ -0,0 +1,127 @@
+package services
+
+import (
+	"sync"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"github.com/srad/mediasink/database"
+)
+
+// captureSession Groups the parts of one broadcast, which might have been interrupted by reconnects.
+type captureSession struct {
+	id        database.SessionID
+	startedAt time.Time
+	endedAt   time.Time
+	// Closes the session if the stream does not come back within the reconnect grace period.
+	timer *time.Timer
+}
+
+var (
+	sessions     = make(map[database.ChannelID]*captureSession)
+	sessionsLock sync.Mutex
+)
+
+// resumeSession Continues the session of the channel if it is still within its reconnect grace period,
+// otherwise a new session is started.
+func resumeSession(id database.ChannelID) *captureSession {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+
+	if session, ok := sessions[id]; ok && session.timer != nil && session.timer.Stop() {
+		session.timer = nil
+		log.Infof("[Session] Stream of channel %d reconnected, continuing session %s", id, session.id)
+		addChannelSessionEvent(id, &session.id, database.ChannelSessionEventReconnect, "")
+		return session
+	}
+
+	session := &captureSession{id: database.NewSessionID(), startedAt: time.Now()}
+	sessions[id] = session
+
+	if _, err := database.OpenChannelSession(id, session.id, session.startedAt); err != nil {
+		log.Errorf("[Session] Error persisting session %s: %s", session.id, err)
+	}
+	addChannelSessionEvent(id, &session.id, database.ChannelSessionEventOnline, "")
+
+	return session
+}
+
+// endSession Is called when the last part of a capture finished. The session stays open for the reconnect
+// grace period of the channel, unless the capture has been terminated on purpose.
+func endSession(channel *database.Channel, session *captureSession) {
+	sessionsLock.Lock()
+	defer sessionsLock.Unlock()
+
+	session.endedAt = time.Now()
+
+	if channel.ReconnectGrace == 0 || IsTerminating(channel.ChannelID) {
+		delete(sessions, channel.ChannelID)
+		go closeSession(channel.ChannelID, session)
+		return
+	}
+
+	log.Infof("[Session] Waiting %ds for channel %s to reconnect to session %s", channel.ReconnectGrace, channel.ChannelName, session.id)
+	session.timer = time.AfterFunc(time.Duration(channel.ReconnectGrace)*time.Second, func() {
+		sessionsLock.Lock()
+		if sessions[channel.ChannelID] == session {
+			delete(sessions, channel.ChannelID)
+		}
+		sessionsLock.Unlock()
+
+		closeSession(channel.ChannelID, session)
+	})
+}
+
+// closeSession Applies the minimum duration of the channel to the entire session and merges its parts if requested.
+func closeSession(id database.ChannelID, session *captureSession) {
+	if err := session.id.CloseChannelSession(session.endedAt); err != nil {
+		log.Errorf("[Session] Error persisting end of session %s: %s", session.id, err)
+	}
+	addChannelSessionEvent(id, &session.id, database.ChannelSessionEventOffline, "")
+
+	channel, err := database.GetChannelByID(id) // Re-fetch for latest MinDuration
+	if err != nil {
+		log.Errorf("[Session] Error querying channel %d: %s", id, err)
+		return
+	}
+
+	parts, err := session.id.FindRecordings(database.RecordingStatusRecording, database.RecordingStatusFinalizing, database.RecordingStatusReady)
+	if err != nil {
+		log.Errorf("[Session] Error querying recordings of session %s: %s", session.id, err)
+		return
+	}
+
+	duration := session.endedAt.Sub(session.startedAt)
+
+	// Cameras keep every segment, an interruption of the footage is not a reason to discard it.
+	if channel.Type != database.ChannelTypeCamera && duration.Minutes() < float64(channel.MinDuration) {
+		log.Infof("[Session] Discarding session %s of %s because it is too short (%fmin)", session.id, channel.ChannelName, duration.Minutes())
+		for _, part := range parts {
+			discardRecording(part)
+		}
+		return
+	}
+
+	if !channel.AutoMerge || len(parts) < 2 {
+		return
+	}
+
+	// The job queue is processed in order, so the parts are finalized before they are merged.
+	if _, err := parts[0].EnqueueMergeJob(session.id); err != nil {
+		log.Errorf("[Session] Error enqueuing merge job for session %s: %s", session.id, err)
+	}
+}
+
+// discardRecording Deletes the files and jobs of a recording, the database entry remains as discarded.
+func discardRecording(recording *database.Recording) {
+	if err := database.DestroyJobs(recording.RecordingID); err != nil {
+		log.Errorf("[Session] Error deleting jobs of '%s': %s", recording.Filename, err)
+	}
+	if err := database.DeleteFile(recording.ChannelName, recording.Filename); err != nil {
+		log.Errorf("[Session] Error deleting '%s': %s", recording.Filename, err)
+	}
+	if err := recording.DestroyPreviews(); err != nil {
+		log.Errorf("[Session] Error deleting previews of '%s': %s", recording.Filename, err)
+	}
+	setRecordingStatus(recording, database.RecordingStatusDiscarded)
+}
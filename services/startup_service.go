@@ -1,6 +1,8 @@
 package services
 
 import (
+	"os"
+
 	"github.com/astaxie/beego/utils"
 	log "github.com/sirupsen/logrus"
 	"github.com/srad/mediasink/database"
@@ -16,6 +18,19 @@ func StartUpJobs() {
 	if err := deleteOrphanedRecordings(); err != nil { // Blocking
 		log.Errorln(err)
 	}
+	// Jobs which were running when the server stopped are picked up again.
+	if err := database.DeactivateJobs(); err != nil {
+		log.Errorf("[StartUpJobs] Error resetting active jobs: %s", err)
+	}
+	if err := recoverCaptures(); err != nil { // Blocking
+		log.Errorf("[RecoverCaptures] Error: %s", err)
+	}
+	if err := recoverOrphanedCaptures(); err != nil { // Blocking
+		log.Errorf("[RecoverCaptures] Error: %s", err)
+	}
+	if err := closeInterruptedChannelSessions(); err != nil {
+		log.Errorf("[History] Error closing interrupted sessions: %s", err)
+	}
 	StartImport()
 	go fixOrphanedFiles()
 }
@@ -27,6 +42,10 @@ func deleteOrphanedRecordings() error {
 	}
 
 	for _, recording := range recordings {
+		// Captures are handled by recoverCaptures, failed and discarded recordings have no file.
+		if recording.Status != database.RecordingStatusReady {
+			continue
+		}
 		filePath := recording.ChannelName.AbsoluteChannelFilePath(recording.Filename)
 		if !utils.FileExists(filePath) {
 			recording.DestroyRecording()
@@ -52,6 +71,95 @@ func deleteChannels() error {
 	return nil
 }
 
+// recoverCaptures Continues the lifecycle of recordings which were interrupted by a crash or restart.
+// Captures are readable up to the last written packet, only unreadable files are deleted.
+func recoverCaptures() error {
+	recordings, err := database.RecordingsListByStatus(database.RecordingStatusRecording, database.RecordingStatusFinalizing)
+	if err != nil {
+		return err
+	}
+
+	for _, recording := range recordings {
+		if err := os.RemoveAll(recording.LiveFolder()); err != nil {
+			log.Errorf("[RecoverCaptures] Error deleting live folder of '%s': %s", recording.Filename, err)
+		}
+
+		if recording.Status == database.RecordingStatusRecording {
+			if _, errInfo := database.GetVideoInfo(recording.ChannelName, recording.Filename); errInfo != nil {
+				log.Errorf("[RecoverCaptures] Capture '%s' is unreadable, deleting: %s", recording.Filename, errInfo)
+				if errDelete := database.DeleteFile(recording.ChannelName, recording.Filename); errDelete != nil {
+					log.Errorf("[RecoverCaptures] Error deleting '%s': %s", recording.Filename, errDelete)
+				}
+				setRecordingStatus(recording, database.RecordingStatusFailed)
+				continue
+			}
+			setRecordingStatus(recording, database.RecordingStatusFinalizing)
+		}
+
+		log.Infof("[RecoverCaptures] Finalizing capture: %s/%s", recording.ChannelName, recording.Filename)
+		if _, errJob := recording.EnqueueFinalizeJob(); errJob != nil {
+			log.Errorf("[RecoverCaptures] Error enqueuing finalize job for '%s': %s", recording.Filename, errJob)
+		}
+	}
+
+	return nil
+}
+
+// recoverOrphanedCaptures Imports and finalizes captures on disk which have no recording, i.e. when the server stopped
+// before the recording was stored. Unreadable captures are deleted.
+func recoverOrphanedCaptures() error {
+	channels, err := database.ChannelList()
+	if err != nil {
+		return err
+	}
+
+	for _, channel := range channels {
+		files, err := os.ReadDir(channel.ChannelName.AbsoluteChannelPath())
+		if err != nil {
+			continue
+		}
+
+		for _, file := range files {
+			filename := database.RecordingFileName(file.Name())
+			if file.IsDir() || !filename.IsCapture() {
+				continue
+			}
+
+			// Captures with a recording are handled by recoverCaptures.
+			existing, errFind := database.FindRecordingByFilename(channel.ChannelName, filename)
+			if errFind != nil {
+				log.Errorf("[RecoverCaptures] Error reading recording of '%s': %s", filename, errFind)
+				continue
+			}
+			if existing != nil {
+				continue
+			}
+
+			if _, errInfo := database.GetVideoInfo(channel.ChannelName, filename); errInfo != nil {
+				log.Errorf("[RecoverCaptures] Capture '%s' is unreadable, deleting: %s", filename, errInfo)
+				if errDelete := database.DeleteFile(channel.ChannelName, filename); errDelete != nil {
+					log.Errorf("[RecoverCaptures] Error deleting '%s': %s", filename, errDelete)
+				}
+				continue
+			}
+
+			recording, errCreate := database.CreateRecording(channel.ChannelID, filename, "recording")
+			if errCreate != nil {
+				log.Errorf("[RecoverCaptures] Error adding capture '%s': %s", filename, errCreate)
+				continue
+			}
+			setRecordingStatus(recording, database.RecordingStatusFinalizing)
+
+			log.Infof("[RecoverCaptures] Finalizing orphaned capture: %s/%s", channel.ChannelName, filename)
+			if _, errJob := recording.EnqueueFinalizeJob(); errJob != nil {
+				log.Errorf("[RecoverCaptures] Error enqueuing finalize job for '%s': %s", filename, errJob)
+			}
+		}
+	}
+
+	return nil
+}
+
 // fixOrphanedFiles Scans the recording folder and checks if an un-imported file is found on the disk.
 // Only uncorrupted files will be imported.
 func fixOrphanedFiles() error {
@@ -79,6 +187,9 @@ func fixOrphanedFiles() error {
 	}
 
 	for _, recording := range recordings {
+		if recording.Status != database.RecordingStatusReady {
+			continue
+		}
 		log.Infof("Handling channel file %s", recording.AbsoluteChannelFilepath())
 		err := helpers.CheckVideo(recording.AbsoluteChannelFilepath())
 		if err != nil {
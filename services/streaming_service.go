@@ -1,7 +1,7 @@
 package services
 
 import (
-	"bytes" // Added for ProcessList and improved Stderr handling
+	"bufio"
 	"context"
 	"errors"
 	"fmt"
@@ -9,6 +9,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"sync" // Added for sync.Mutex
 	"syscall"
@@ -19,6 +20,7 @@ import (
 	"github.com/srad/mediasink/database"
 	"github.com/srad/mediasink/helpers"
 	"github.com/srad/mediasink/network"
+	"github.com/srad/mediasink/resolvers"
 )
 
 type StreamInfo struct {
@@ -26,25 +28,51 @@ type StreamInfo struct {
 	IsTerminating bool                 `extensions:"!x-nullable"`
 	URL           string               `extensions:"!x-nullable"`
 	ChannelName   database.ChannelName `json:"channelName" extensions:"!x-nullable"`
+	Title         string               `json:"title" extensions:"!x-nullable"`
+	InputArgs     []string             `json:"-"`
+	AudioOnly     bool                 `json:"-"`
+	Ingest        bool                 `json:"-"`
+	Timer         database.TimerID     `json:"-"` // The timer whose URL is captured, see applyRecordingTimer
 }
 
 type ProcessInfo struct {
-	ID     database.ChannelID `json:"id"`
-	Pid    int                `json:"pid"`
-	Path   string             `json:"path"`
-	Args   string             `json:"args"`
-	Output string             `json:"output"`
+	ID       database.ChannelID `json:"id"`
+	Pid      int                `json:"pid"`
+	Path     string             `json:"path"`
+	Args     string             `json:"args"`
+	Output   string             `json:"output"` // The last lines of stderr
+	Progress *CaptureProgress   `json:"progress"`
 }
 
+// CaptureProgress Telemetry of a running capture, as reported by ffmpeg. Values ffmpeg does not know are 0.
+type CaptureProgress struct {
+	ChannelID     database.ChannelID   `json:"channelId" extensions:"!x-nullable"`
+	RecordingID   database.RecordingID `json:"recordingId" extensions:"!x-nullable"`
+	Elapsed       float64              `json:"elapsed" extensions:"!x-nullable"` // Seconds of captured media
+	Bitrate       float64              `json:"bitrate" extensions:"!x-nullable"` // kbit/s
+	Size          int64                `json:"size" extensions:"!x-nullable"`    // Bytes
+	Fps           float64              `json:"fps" extensions:"!x-nullable"`
+	Speed         float64              `json:"speed" extensions:"!x-nullable"`
+	DroppedFrames int64                `json:"droppedFrames" extensions:"!x-nullable"`
+	UpdatedAt     time.Time            `json:"updatedAt" extensions:"!x-nullable"`
+}
+
+// resolverScriptsFolder Folder within the data path which contains the executables of the script resolver.
+const resolverScriptsFolder = "resolvers"
+
+// cookiesFolder Folder within the data path which contains the cookies files of the channels.
+const cookiesFolder = "cookies"
+
 var (
 	// Package-level maps that need protection
 	recInfo    = make(map[database.ChannelID]*database.Recording)
 	streamInfo = make(map[database.ChannelID]StreamInfo)
 	streams    = make(map[database.ChannelID]*exec.Cmd)
+	parts      = make(map[database.ChannelID]*capturePart)
 
 	// Mutexes for protecting concurrent access to the maps
 	streamInfoLock sync.Mutex
-	activeRecLock  sync.Mutex // Protects recInfo and streams
+	activeRecLock  sync.Mutex // Protects recInfo, streams and parts
 )
 
 // Screenshot method on StreamInfo itself is fine as it operates on its own fields.
@@ -53,11 +81,317 @@ func (si *StreamInfo) Screenshot() error {
 	// Ensure conf.FrameWidth and other path components are valid.
 	// This function itself doesn't modify global maps.
 	// Using absolute path for ffmpeg in helpers.ExtractFirstFrame is recommended.
-	return helpers.ExtractFirstFrame(si.URL, conf.FrameWidth, filepath.Join(si.ChannelName.AbsoluteChannelDataPath(), database.SnapshotFilename))
+	return liveSnapshot(si.ChannelName, si.URL, si.AudioOnly, si.InputArgs)
+}
+
+// liveSnapshot Audio-only streams have no frames, their snapshot is the waveform of the first seconds.
+func liveSnapshot(channelName database.ChannelName, url string, audioOnly bool, inputArgs []string) error {
+	output := filepath.Join(channelName.AbsoluteChannelDataPath(), database.SnapshotFilename)
+	if audioOnly {
+		return helpers.ExtractWaveform(url, helpers.WaveformSize, output, snapshotAudioSeconds, inputArgs...)
+	}
+	return helpers.ExtractFirstFrame(url, conf.FrameWidth, output, inputArgs...)
+}
+
+// resolverRequest The options of the channel which are passed to the resolvers and yt-dlp.
+func resolverRequest(channel *database.Channel) resolvers.Request {
+	request := resolvers.Request{
+		URL:           channel.URL,
+		Format:        channel.FormatSelector,
+		MaxResolution: channel.MaxResolution,
+		Proxy:         channel.Proxy,
+	}
+	if channel.CapturesAudioOnly() && request.Format == "" {
+		request.Format = "bestaudio/best"
+	}
+	if channel.ResolverScript != "" {
+		// Only scripts placed in the data folder by the administrator can be executed.
+		request.Script = filepath.Join(conf.Read().DataPath, resolverScriptsFolder, filepath.Base(channel.ResolverScript))
+	}
+	if channel.CookiesFile != "" {
+		request.Cookies = filepath.Join(conf.Read().DataPath, cookiesFolder, filepath.Base(channel.CookiesFile))
+	}
+	if len(channel.Headers) > 0 || channel.UserAgent != "" {
+		request.Headers = make(map[string]string, len(channel.Headers)+1)
+		for key, value := range channel.Headers {
+			request.Headers[key] = value
+		}
+		if channel.UserAgent != "" {
+			request.Headers["User-Agent"] = channel.UserAgent
+		}
+	}
+
+	return request
+}
+
+// resolveStream Queries the media URL of the channel with its configured resolver.
+// The capture options of the channel are applied to the resolution and carried over to ffmpeg.
+func resolveStream(channel *database.Channel) (*resolvers.Result, error) {
+	request := resolverRequest(channel)
+
+	// Icecast/Shoutcast and camera URLs point to the stream itself.
+	name := resolvers.Name(channel.Resolver)
+	if name == "" && (channel.Type == database.ChannelTypeRadio || channel.Type == database.ChannelTypeCamera) {
+		name = resolvers.Direct
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+	defer cancel()
+
+	return resolvers.Resolve(ctx, name, request)
+}
+
+// capturePart A single ffmpeg process of a capture session which writes one recording file.
+type capturePart struct {
+	cmd        *exec.Cmd
+	recording  *database.Recording
+	outputPath string
+	startedAt  time.Time
+	stderr     *lineBuffer
+	stdin      io.WriteCloser // Only for piped captures, see capturePipe
+	skip       uint           // Seconds skipped at the start of the input, only the first part of a capture skips
+	done       chan error
+
+	// Parsed from the -progress output of ffmpeg.
+	progressLock    sync.Mutex
+	progress        CaptureProgress
+	totalSize       int64
+	outTime         int64     // Microseconds
+	lastPacketAt    time.Time // Last time the output advanced
+	lastBroadcastAt time.Time
+}
+
+var errCaptureStalled = errors.New("capture stalled")
+
+func newCapturePart(channel *database.Channel, stream *resolvers.Result, skip uint, sessionID database.SessionID) (*capturePart, error) {
+	recording, outputFilePath, err := database.NewCaptureRecording(channel.ChannelID, "recording")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create new recording entry for %s: %w", channel.ChannelName, err)
+	}
+	metadata := streamMetadata(stream)
+	applyStreamMetadata(recording, metadata)
+
+	// The recording is persisted before ffmpeg starts, so that it survives a crash.
+	if err := database.CreateCaptureRecording(recording, sessionID); err != nil {
+		return nil, err
+	}
+	if metadata.Title != "" || metadata.Category != "" {
+		if errChange := database.AddRecordingTitleChange(recording.RecordingID, metadata.Title, metadata.Category, *recording.StartedAt); errChange != nil {
+			log.Errorf("[Capture] Error adding title of '%s': %v", recording.Filename, errChange)
+		}
+	}
+	network.BroadCastClients(network.RecordingStatusEvent, recording)
+
+	// The HLS playlist is a second output of the same process, so the origin is only contacted once.
+	// It serves the live relay and the timeshift playback of the part, until the part is finished.
+	liveFolder := recording.LiveFolder()
+	if err := os.MkdirAll(liveFolder, os.ModePerm); err != nil {
+		return nil, fmt.Errorf("failed to create live folder for %s: %w", channel.ChannelName, err)
+	}
+
+	// Audio-only captures keep the first audio stream as it is, it is converted to the codec of the channel when finalized.
+	var mapArgs []string
+	if channel.CapturesAudioOnly() {
+		mapArgs = []string{"-map", "0:a:0", "-vn"}
+	}
+
+	// Radio streams are read by the ICY reader, which passes on the audio data without the metadata.
+	// Published streams are received by the ingest listener.
+	input, inputArgs := stream.URL, stream.InputArgs()
+	if channel.Type == database.ChannelTypeRadio || channel.Type == database.ChannelTypeIngest {
+		input, inputArgs = "pipe:0", nil
+	}
+
+	cmdArgs := []string{"-hide_banner", "-loglevel", "error", "-nostats", "-progress", "pipe:1"}
+	cmdArgs = append(cmdArgs, inputArgs...)
+	cmdArgs = append(cmdArgs, "-i", input, "-ss", fmt.Sprintf("%d", skip))
+	cmdArgs = append(cmdArgs, mapArgs...)
+	cmdArgs = append(cmdArgs, "-c", "copy", "-f", "mpegts", outputFilePath)
+	cmdArgs = append(cmdArgs, mapArgs...)
+	cmdArgs = append(cmdArgs, helpers.HLSOutputArgs(liveFolder, liveSegmentDuration)...)
+
+	part := &capturePart{
+		cmd:        exec.Command("ffmpeg", cmdArgs...),
+		recording:  recording,
+		outputPath: outputFilePath,
+		skip:       skip,
+		stderr:     newLineBuffer(stderrLines),
+		done:       make(chan error, 1),
+		progress:   CaptureProgress{ChannelID: channel.ChannelID, RecordingID: recording.RecordingID},
+	}
+	// exec copies stderr into the buffer and Wait() only returns once the copy is complete.
+	part.cmd.Stderr = part.stderr
+
+	if input == "pipe:0" {
+		if part.stdin, err = part.cmd.StdinPipe(); err != nil {
+			return nil, err
+		}
+	}
+
+	return part, nil
+}
+
+func (part *capturePart) start() error {
+	log.Infof("Executing: %s", strings.Join(part.cmd.Args, " "))
+
+	stdout, err := part.cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+
+	if err := part.cmd.Start(); err != nil {
+		return err
+	}
+	part.startedAt = time.Now()
+	part.lastPacketAt = part.startedAt
+
+	go func() {
+		// Wait() closes the pipe, so the output must be read entirely before.
+		part.readProgress(stdout)
+		part.done <- part.cmd.Wait()
+	}()
+
+	return nil
+}
+
+// readProgress Parses the blocks of key=value lines, which ffmpeg writes for -progress, until the process exits.
+func (part *capturePart) readProgress(stdout io.Reader) {
+	scanner := bufio.NewScanner(stdout)
+	var block strings.Builder
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		block.WriteString(line)
+		block.WriteString("\n")
+
+		// Each block ends with progress=continue or progress=end.
+		if strings.HasPrefix(line, "progress=") {
+			part.updateProgress(helpers.ParseFFmpegKVs(block.String()))
+			block.Reset()
+		}
+	}
+}
+
+// updateProgress Tracks the progress for the stall watchdog and pushes it to the clients at a throttled rate.
+func (part *capturePart) updateProgress(kvs map[string]string) {
+	part.progressLock.Lock()
+	defer part.progressLock.Unlock()
+
+	now := time.Now()
+	totalSize, _ := strconv.ParseInt(kvs["total_size"], 10, 64)
+	outTime, _ := strconv.ParseInt(kvs["out_time_us"], 10, 64)
+
+	if totalSize > part.totalSize || outTime > part.outTime {
+		part.lastPacketAt = now
+	}
+	part.totalSize = max(part.totalSize, totalSize)
+	part.outTime = max(part.outTime, outTime)
+
+	// Unknown values are reported as N/A and parse to 0.
+	part.progress.Elapsed = float64(part.outTime) / 1e6
+	part.progress.Size = part.totalSize
+	part.progress.Bitrate, _ = strconv.ParseFloat(strings.TrimSuffix(kvs["bitrate"], "kbits/s"), 64)
+	part.progress.Fps, _ = strconv.ParseFloat(kvs["fps"], 64)
+	part.progress.Speed, _ = strconv.ParseFloat(strings.TrimSuffix(kvs["speed"], "x"), 64)
+	part.progress.DroppedFrames, _ = strconv.ParseInt(kvs["drop_frames"], 10, 64)
+	part.progress.UpdatedAt = now
+
+	if now.Sub(part.lastBroadcastAt) >= progressEventInterval {
+		part.lastBroadcastAt = now
+		network.BroadCastClients(network.ChannelProgressEvent, part.progress)
+	}
 }
 
-// CaptureChannel Starts and also waits for the stream to end or being killed
-func CaptureChannel(id database.ChannelID, url string, skip uint) error {
+func (part *capturePart) currentProgress() CaptureProgress {
+	part.progressLock.Lock()
+	defer part.progressLock.Unlock()
+	return part.progress
+}
+
+// stalledFor Time since the output of ffmpeg advanced the last time.
+func (part *capturePart) stalledFor() time.Duration {
+	part.progressLock.Lock()
+	defer part.progressLock.Unlock()
+	return time.Since(part.lastPacketAt)
+}
+
+// stallTimeout The stall timeout of the channel, channels which have none use the default.
+func stallTimeout(channel *database.Channel) time.Duration {
+	if channel.StallTimeout == 0 {
+		return defaultStallTimeout
+	}
+	return time.Duration(channel.StallTimeout) * time.Second
+}
+
+// isStalled Checks the part against the stall timeout of the channel, unless the channel disabled the watchdog.
+func (part *capturePart) isStalled(channel *database.Channel) bool {
+	return !channel.DisableStallWatchdog && part.stalledFor() >= stallTimeout(channel)
+}
+
+// StalledCapture Payload of the stalled event.
+type StalledCapture struct {
+	ChannelID   database.ChannelID   `json:"channelId" extensions:"!x-nullable"`
+	ChannelName database.ChannelName `json:"channelName" extensions:"!x-nullable"`
+	RecordingID database.RecordingID `json:"recordingId" extensions:"!x-nullable"`
+	Seconds     uint                 `json:"seconds" extensions:"!x-nullable"`
+}
+
+func reportStall(channel *database.Channel, part *capturePart, sessionID database.SessionID) {
+	seconds := uint(part.stalledFor().Seconds())
+	log.Warnf("[Capture] No data received for %s for %ds, restarting capture", channel.ChannelName, seconds)
+
+	addChannelSessionEvent(channel.ChannelID, &sessionID, database.ChannelSessionEventStalled, fmt.Sprintf("no data received for %ds", seconds))
+	network.BroadCastClients(network.ChannelStalledEvent, StalledCapture{
+		ChannelID:   channel.ChannelID,
+		ChannelName: channel.ChannelName,
+		RecordingID: part.recording.RecordingID,
+		Seconds:     seconds,
+	})
+}
+
+// stop Interrupts ffmpeg and kills it, if it does not exit in time, i.e. because it is stuck on a dead connection.
+func (part *capturePart) stop() {
+	if err := part.cmd.Process.Signal(os.Interrupt); err != nil {
+		log.Errorf("[Capture] Error interrupting '%s': %v", part.outputPath, err)
+	}
+	process := part.cmd.Process
+	time.AfterFunc(rolloverTimeout, func() {
+		// Returns os.ErrProcessDone once the process has been waited for.
+		_ = process.Kill()
+	})
+}
+
+func (part *capturePart) size() int64 {
+	if stat, err := os.Stat(part.outputPath); err == nil {
+		return stat.Size()
+	}
+	return 0
+}
+
+// exceedsSegmentPolicy Checks if the part reached the channel's maximum segment duration or size.
+// Camera segments always have a fixed length and end at the clock boundaries, i.e. 10:00, 10:10, ...
+func (part *capturePart) exceedsSegmentPolicy(channel *database.Channel) bool {
+	if channel.Type == database.ChannelTypeCamera {
+		segment := cameraSegment(channel)
+		return !part.startedAt.Truncate(segment).Equal(time.Now().Truncate(segment))
+	}
+	if channel.SegmentDuration > 0 && time.Since(part.startedAt) >= time.Duration(channel.SegmentDuration)*time.Minute {
+		return true
+	}
+	if channel.SegmentSize > 0 && part.size() >= int64(channel.SegmentSize)*1024*1024 {
+		return true
+	}
+	return false
+}
+
+// CaptureChannel Starts and also waits for the stream to end or being killed.
+// If the channel defines a segment policy, the capture is split into multiple recordings of the same session.
+// A capture which starts within the reconnect grace period of the previous one continues its session.
+func CaptureChannel(id database.ChannelID, stream *resolvers.Result, skip uint) error {
+	if DiskLevel() == DiskLevelCritical {
+		return fmt.Errorf("CaptureChannel: not enough disk space to capture channel %d", id)
+	}
+
 	channel, err := database.GetChannelByID(id)
 	if err != nil {
 		return fmt.Errorf("CaptureChannel: failed to get channel %d: %w", id, err)
@@ -77,56 +411,253 @@ func CaptureChannel(id database.ChannelID, url string, skip uint) error {
 		return fmt.Errorf("CaptureChannel: failed to create directory for %s: %w", channel.ChannelName, errMkDir)
 	}
 
-	recording, outputFilePath, err := database.NewRecording(channel.ChannelID, "recording")
+	session := resumeSession(id)
+	sessionID := session.id
+
+	part, err := newCapturePart(channel, stream, skip, sessionID)
 	if err != nil {
 		activeRecLock.Unlock() // Unlock before returning error
-		return fmt.Errorf("CaptureChannel: failed to create new recording entry for %s: %w", channel.ChannelName, err)
+		return fmt.Errorf("CaptureChannel: %w", err)
 	}
 
+	// Store in maps under lock
+	recInfo[id] = part.recording
+	streams[id] = part.cmd
+	parts[id] = part
+	activeRecLock.Unlock() // Unlock after map modifications, before blocking operations (Start/Wait)
+
 	log.Infoln("----------------------------------------Capturing----------------------------------------")
-	log.Infof("URL: %s", url)
-	log.Infof("To: %s", outputFilePath)
+	log.Infof("URL: %s", stream.URL)
+	log.Infof("To: %s", part.outputPath)
+	log.Infof("Session: %s", sessionID)
 
-	ffmpegExecutable := "ffmpeg"
-	cmdArgs := []string{"-hide_banner", "-loglevel", "error", "-i", url, "-ss", fmt.Sprintf("%d", skip), "-movflags", "faststart", "-c", "copy", outputFilePath}
-	cmdToRun := exec.Command(ffmpegExecutable, cmdArgs...)
+	if err := part.start(); err != nil {
+		log.Errorf("[Capture] cmd.Start failed for %s: %v", channel.ChannelName, err)
+		setRecordingStatus(part.recording, database.RecordingStatusFailed)
+		endSession(channel, session)
+		// The calling goroutine in Start() will call DeleteStreamData to clean up map entries.
+		return fmt.Errorf("ffmpeg cmd.Start failed for %s: %w", channel.ChannelName, err)
+	}
+	log.Infof("[Capture] ffmpeg process started for %s (PID: %d)", channel.ChannelName, part.cmd.Process.Pid)
 
-	// Store in maps under lock
-	recInfo[id] = recording
-	streams[id] = cmdToRun
-	activeRecLock.Unlock() // Unlock after map modifications, before blocking operations (Start/Wait)
+	ticker := time.NewTicker(segmentCheckInterval)
+	defer ticker.Stop()
 
-	log.Infof("Executing: %s %s", ffmpegExecutable, strings.Join(cmdArgs, " "))
+	// The metadata is refreshed in the background, resolving may take a while.
+	metadataTicker := time.NewTicker(metadataRefreshInterval)
+	defer metadataTicker.Stop()
+	refreshed := make(chan *resolvers.Result, 1)
+	refreshing := false
 
-	var stderrBuf bytes.Buffer
-	stderrPipe, pipeErr := cmdToRun.StderrPipe()
-	if pipeErr != nil {
-		log.Errorf("[Capture] Error creating stderr pipe for %s: %v. FFMPEG output may be lost.", channel.ChannelName, pipeErr)
-		// Continue, but be aware stderr might not be captured.
-	} else {
-		// Asynchronously copy Stderr to the buffer.
-		// This goroutine will exit once StderrPipe is closed (after cmdToRun.Wait() completes).
-		go func() {
-			_, errCopy := io.Copy(&stderrBuf, stderrPipe)
-			if errCopy != nil {
-				log.Warnf("[Capture] Error copying stderr for %s: %v", channel.ChannelName, errCopy)
+	stalled := false
+	var backoff rolloverBackoff
+
+	for {
+		select {
+		case <-metadataTicker.C:
+			if refreshing || stalled || IsTerminating(id) || !refreshesMetadata(channel) {
+				continue
+			}
+			refreshing = true
+			go func(channel database.Channel) {
+				refreshed <- refreshMetadata(channel)
+			}(*channel)
+
+		case latest := <-refreshed:
+			refreshing = false
+			if latest != nil && !IsTerminating(id) {
+				updateStreamMetadata(channel, part, stream, latest)
+				enforceRules(channel, part, stream)
 			}
-		}()
+
+		case waitErr := <-part.done:
+			// The stream ended or was terminated, this is the last part of this capture.
+			errFinish := finishCapturePart(channel, part, waitErr)
+			endSession(channel, session)
+			if stalled {
+				return errors.Join(errCaptureStalled, errFinish)
+			}
+			return errFinish
+
+		case <-ticker.C:
+			if errProgress := part.recording.UpdateProgress(uint64(part.size())); errProgress != nil {
+				log.Errorf("[Capture] Error updating progress of '%s': %v", part.outputPath, errProgress)
+			}
+
+			if stalled || IsTerminating(id) {
+				continue
+			}
+
+			if part.isStalled(channel) {
+				stalled = true
+				reportStall(channel, part, sessionID)
+				part.stop()
+				continue
+			}
+
+			if !part.exceedsSegmentPolicy(channel) || !backoff.ready(time.Now()) {
+				continue
+			}
+
+			// The URL of the current part may have expired, the next part requests the stream again.
+			latest, errResolve := rolloverStream(channel, stream)
+			if errResolve != nil {
+				log.Errorf("[Capture] Rollover failed for %s, continuing current segment, next attempt in %s: %v", channel.ChannelName, backoff.failed(time.Now()), errResolve)
+				continue
+			}
+			stream = latest
+
+			next, errRollover := rolloverCapturePart(channel, part, stream, sessionID)
+			if errRollover != nil {
+				log.Errorf("[Capture] Rollover failed for %s, continuing current segment, next attempt in %s: %v", channel.ChannelName, backoff.failed(time.Now()), errRollover)
+				continue
+			}
+			backoff.reset()
+
+			// The previous part has been interrupted and is finalized in the background.
+			go func(previous *capturePart) {
+				if errFinish := finishCapturePart(channel, previous, <-previous.done); errFinish != nil {
+					log.Errorf("[Capture] Error finishing segment '%s': %v", previous.outputPath, errFinish)
+				}
+			}(part)
+
+			part = next
+		}
 	}
+}
 
-	if err := cmdToRun.Start(); err != nil {
-		// Log stderr if available, even if Start fails (though less likely to have output then)
-		log.Errorf("[Capture] cmd.Start failed for %s: %v. Stderr: %s", channel.ChannelName, err, stderrBuf.String())
-		// The calling goroutine in Start() will call DeleteStreamData to clean up map entries.
-		return fmt.Errorf("ffmpeg cmd.Start failed for %s: %w", channel.ChannelName, err)
+// rolloverBackoff Delays the next rollover after a failed one, so that a broken stream doesn't create a part on every check.
+type rolloverBackoff struct {
+	failures int
+	retryAt  time.Time
+}
+
+func (backoff *rolloverBackoff) ready(now time.Time) bool {
+	return !now.Before(backoff.retryAt)
+}
+
+// failed Doubles the delay with every failed attempt, up to maxRolloverDelay. Returns the delay.
+func (backoff *rolloverBackoff) failed(now time.Time) time.Duration {
+	backoff.failures++
+
+	delay := rolloverRetryDelay
+	for i := 1; i < backoff.failures && delay < maxRolloverDelay; i++ {
+		delay *= 2
 	}
-	log.Infof("[Capture] ffmpeg process started for %s (PID: %d)", channel.ChannelName, cmdToRun.Process.Pid)
+	delay = min(delay, maxRolloverDelay)
 
-	waitErr := cmdToRun.Wait() // Wait for the command to finish
+	backoff.retryAt = now.Add(delay)
+	return delay
+}
 
-	// At this point, the stderr copying goroutine should have finished or will finish soon.
-	// stderrBuf will contain the stderr output.
-	stderrOutput := stderrBuf.String()
+func (backoff *rolloverBackoff) reset() {
+	backoff.failures = 0
+	backoff.retryAt = time.Time{}
+}
+
+// rolloverStream Resolves the stream again for the next part, the metadata is taken over by the next part.
+// Streams which are not resolved by a site, i.e. cameras and direct URLs, keep their URL.
+func rolloverStream(channel *database.Channel, stream *resolvers.Result) (*resolvers.Result, error) {
+	if !refreshesMetadata(channel) {
+		return stream, nil
+	}
+
+	current := *channel
+	applyRecordingTimer(&current)
+
+	latest, err := resolveStream(&current)
+	if err != nil {
+		return nil, err
+	}
+	if latest == nil || latest.URL == "" {
+		return nil, errors.New("stream is offline")
+	}
+
+	return latest, nil
+}
+
+// discardCapturePart Deletes the recording of a part which never wrote any data, i.e. of a failed rollover.
+func discardCapturePart(part *capturePart) {
+	if err := os.RemoveAll(part.recording.LiveFolder()); err != nil {
+		log.Errorf("[Capture] Error deleting live folder of '%s': %v", part.outputPath, err)
+	}
+	if err := part.recording.DestroyRecording(); err != nil {
+		log.Errorf("[Capture] Error deleting recording '%s': %v", part.outputPath, err)
+	}
+	network.BroadCastClients(network.RecordingDeleteEvent, part.recording)
+}
+
+// rolloverCapturePart Starts the next part of the session and only interrupts the current part
+// once the new part writes data, so that no packets get lost between both files.
+func rolloverCapturePart(channel *database.Channel, current *capturePart, stream *resolvers.Result, sessionID database.SessionID) (*capturePart, error) {
+	next, err := newCapturePart(channel, stream, 0, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Infof("[Capture] Segment limit reached for %s, rolling over to: %s", channel.ChannelName, next.outputPath)
+
+	if err := next.start(); err != nil {
+		discardCapturePart(next)
+		return nil, err
+	}
+
+	deadline := time.After(rolloverTimeout)
+	for next.size() == 0 {
+		select {
+		case errNext := <-next.done:
+			discardCapturePart(next)
+			return nil, fmt.Errorf("next segment exited before writing data: %v: %s", errNext, next.stderr.String())
+		case <-deadline:
+			if errInterrupt := next.cmd.Process.Signal(os.Interrupt); errInterrupt != nil {
+				log.Errorf("[Capture] Error interrupting next segment of %s: %v", channel.ChannelName, errInterrupt)
+			}
+			<-next.done
+			discardCapturePart(next)
+			return nil, fmt.Errorf("next segment did not write any data within %s", rolloverTimeout)
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+
+	activeRecLock.Lock()
+	recInfo[channel.ChannelID] = next.recording
+	streams[channel.ChannelID] = next.cmd
+	parts[channel.ChannelID] = next
+	activeRecLock.Unlock()
+
+	// The live snapshots use the URL of the running part.
+	streamInfoLock.Lock()
+	if info, ok := streamInfo[channel.ChannelID]; ok {
+		info.URL = stream.URL
+		info.InputArgs = stream.InputArgs()
+		info.Title = stream.Title
+		streamInfo[channel.ChannelID] = info
+	}
+	streamInfoLock.Unlock()
+
+	if err := current.cmd.Process.Signal(os.Interrupt); err != nil {
+		log.Errorf("[Capture] Error interrupting previous segment '%s': %v", current.outputPath, err)
+	}
+
+	// The capture might have been terminated while the maps still referenced the previous part.
+	if IsTerminating(channel.ChannelID) {
+		if err := next.cmd.Process.Signal(os.Interrupt); err != nil {
+			log.Errorf("[Capture] Error interrupting segment '%s': %v", next.outputPath, err)
+		}
+	}
+
+	return next, nil
+}
+
+// finishCapturePart Registers the file of a finished part as recording and enqueues its finalization.
+// The minimum duration of the channel applies to the entire session and is checked once the session is closed.
+func finishCapturePart(channel *database.Channel, part *capturePart, waitErr error) error {
+	if err := os.RemoveAll(part.recording.LiveFolder()); err != nil {
+		log.Errorf("[Capture] Error deleting live folder of '%s': %v", part.outputPath, err)
+	}
+
+	// At this point, the stderr copying has finished, and it contains the last lines of the output.
+	stderrOutput := part.stderr.String()
 	if len(stderrOutput) > 0 {
 		log.Warnf("[Capture] ffmpeg stderr for %s:\n%s", channel.ChannelName, stderrOutput)
 	}
@@ -136,69 +667,32 @@ func CaptureChannel(id database.ChannelID, url string, skip uint) error {
 		// Check if it's an ExitError and if the code is 255 (often from os.Interrupt)
 		if errors.As(waitErr, &exitErr) && exitErr.Sys().(syscall.WaitStatus).ExitStatus() == 255 {
 			log.Infof("[Capture] ffmpeg for %s exited with status 255 (likely intentional stop via Interrupt).", channel.ChannelName)
+		} else if part.size() > 0 {
+			// MPEG-TS stays readable up to the last written packet, so the data captured so far is kept.
+			log.Errorf("[Capture] ffmpeg process for '%s' exited with error, keeping partial capture: %v", channel.ChannelName, waitErr)
 		} else {
 			log.Errorf("[Capture] ffmpeg process for '%s' exited with error: %v", channel.ChannelName, waitErr)
-			// Attempt to remove the possibly corrupted/incomplete output file
-			if errRemove := os.Remove(outputFilePath); errRemove != nil {
-				log.Errorf("[Capture] Error deleting recording file '%s' after ffmpeg error: %v", outputFilePath, errRemove)
+			if errRemove := os.Remove(part.outputPath); errRemove != nil && !os.IsNotExist(errRemove) {
+				log.Errorf("[Capture] Error deleting recording file '%s' after ffmpeg error: %v", part.outputPath, errRemove)
 			}
+			setRecordingStatus(part.recording, database.RecordingStatusFailed)
 			return fmt.Errorf("ffmpeg process for %s failed: %w", channel.ChannelName, waitErr)
 		}
 	} else {
 		log.Infof("[Capture] ffmpeg process for %s finished successfully.", channel.ChannelName)
 	}
 
-	recDuration := time.Since(recording.CreatedAt)
-
-	// Determine minimum required duration
-	defaultMinDurationMinutes := 1.0 // 1min, default if DB query fails or not set
-	channelDuration := defaultMinDurationMinutes
-
-	currentChannelState, errChannel := database.GetChannelByID(id) // Re-fetch for latest MinDuration
-	if errChannel == nil {
-		channelDuration = float64(currentChannelState.MinDuration) // Use DB value
-		log.Infof("[Capture] Minimum recording duration for channel %s is %f min (from DB).", currentChannelState.ChannelName, channelDuration)
-	} else {
-		log.Errorf("[Capture] Error querying channel %s (ID: %d) for MinDuration: %v. Using default %f min.", recording.ChannelName, id, errChannel, channelDuration)
+	if err := part.recording.UpdateProgress(uint64(part.size())); err != nil {
+		log.Errorf("[Capture] Error updating progress of '%s': %s", part.outputPath, err)
 	}
+	setRecordingStatus(part.recording, database.RecordingStatusFinalizing)
 
-	if recDuration.Minutes() >= channelDuration {
-		activeRecLock.Lock()
-		recToFinalize, ok := recInfo[id]
-		activeRecLock.Unlock()
-
-		if ok && recToFinalize.Filename == recording.Filename {
-			info := Info(id)
-			if newRecording, err := database.CreateRecording(info.ChannelID, info.Filename, "recording"); err != nil {
-				log.Errorf("[Info] Error adding recording '%s': %s", outputFilePath, err)
-			} else {
-				network.BroadCastClients(network.RecordingAddEvent, newRecording)
-
-				if _, _, errPreviews := newRecording.EnqueuePreviewsJob(); errPreviews != nil {
-					return err
-				}
-			}
-		} else { // Throw away
-			log.Infof("[FinishRecording] Deleting stream '%s/%s' because it is too short (%fmin)", channel.ChannelName, recording.Filename, recDuration.Minutes())
-
-			if err := os.Remove(outputFilePath); err != nil {
-				log.Errorf("[Capture] Error destroying recording: %s", err)
-			}
-		}
+	// The recording is announced to clients once the finalize job has produced the mp4 file.
+	if _, errFinalize := part.recording.EnqueueFinalizeJob(); errFinalize != nil {
+		return errFinalize
 	}
-	return nil
-}
 
-func GetRecordingMinutes(id database.ChannelID) float64 {
-	activeRecLock.Lock()
-	defer activeRecLock.Unlock()
-	if rec, ok := recInfo[id]; ok {
-		// Also check if the stream process is still tracked
-		if _, streamOk := streams[id]; streamOk {
-			return time.Since(rec.CreatedAt).Minutes()
-		}
-	}
-	return 0
+	return nil
 }
 
 func Info(id database.ChannelID) *database.Recording {
@@ -210,65 +704,142 @@ func Info(id database.ChannelID) *database.Recording {
 	return recInfo[id]
 }
 
+// applyRecordingTimer A timer may record another URL into this channel. Returns the timer, if its URL has been applied.
+func applyRecordingTimer(channel *database.Channel) database.TimerID {
+	if timer, err := channel.ChannelID.FindActiveRecordingTimer(time.Now()); err != nil {
+		log.Errorf("[Start] Error querying timers of %s: %v", channel.ChannelName, err)
+	} else if timer != nil && timer.URL != "" {
+		channel.URL = timer.URL
+		return timer.TimerID
+	}
+	return 0
+}
+
+// capturedTimer The timer whose URL the channel is capturing, 0 if it captures its own URL.
+func capturedTimer(id database.ChannelID) database.TimerID {
+	streamInfoLock.Lock()
+	defer streamInfoLock.Unlock()
+	return streamInfo[id].Timer
+}
+
+// startResult The outcome of startChannel.
+type startResult int
+
+const (
+	startOffline startResult = iota
+	startStarted
+	startSkipped // The broadcast is online, but rejected by the capture rules of the channel.
+)
+
 func Start(id database.ChannelID) (bool, error) {
+	result, err := startChannel(id)
+	return result == startStarted, err
+}
+
+// startChannel Resolves the stream of the channel and starts its capture in the background.
+func startChannel(id database.ChannelID) (startResult, error) {
 	channel, err := database.GetChannelByID(id)
 	if err != nil {
-		return false, fmt.Errorf("start: failed to get channel %d: %w", id, err)
+		return startOffline, fmt.Errorf("start: failed to get channel %d: %w", id, err)
 	}
 
 	// Assuming id.PauseChannel(false) is a DB operation and thread-safe in itself
 	if err := id.PauseChannel(false); err != nil {
-		return false, fmt.Errorf("start: failed to unpause channel %d: %w", id, err)
+		return startOffline, fmt.Errorf("start: failed to unpause channel %d: %w", id, err)
 	}
 
-	url, queryErr := channel.QueryStreamURL() // Assuming QueryStreamURL is thread-safe
+	// The capture of an ingest channel is started by its publisher.
+	if channel.Type == database.ChannelTypeIngest {
+		return startOffline, nil
+	}
 
-	// This was the panic site for "concurrent map writes"
-	streamInfoLock.Lock()
-	var currentIsTerminating bool
-	// Check if entry exists to preserve IsTerminating if already set
-	if siExisting, ok := streamInfo[channel.ChannelID]; ok {
-		currentIsTerminating = siExisting.IsTerminating
+	timer := applyRecordingTimer(channel)
+
+	stream, queryErr := resolveStream(channel)
+	if stream == nil {
+		stream = &resolvers.Result{}
 	}
-	streamInfo[channel.ChannelID] = StreamInfo{
-		IsOnline:      url != "" && queryErr == nil, // Mark online only if URL found AND no query error
-		URL:           url,
-		ChannelName:   channel.ChannelName,
-		IsTerminating: currentIsTerminating,
+	url := stream.URL
+
+	setStreamInfo := func(online bool) {
+		// This was the panic site for "concurrent map writes"
+		streamInfoLock.Lock()
+		defer streamInfoLock.Unlock()
+		var currentIsTerminating bool
+		// Check if entry exists to preserve IsTerminating if already set
+		if siExisting, ok := streamInfo[channel.ChannelID]; ok {
+			currentIsTerminating = siExisting.IsTerminating
+		}
+		streamInfo[channel.ChannelID] = StreamInfo{
+			IsOnline:      online,
+			URL:           url,
+			ChannelName:   channel.ChannelName,
+			IsTerminating: currentIsTerminating,
+			Title:         stream.Title,
+			InputArgs:     stream.InputArgs(),
+			AudioOnly:     channel.CapturesAudioOnly(),
+			Timer:         timer,
+		}
 	}
-	streamInfoLock.Unlock()
 
+	logResolverError(id, queryErr)
 	if queryErr != nil {
+		setStreamInfo(false)
 		log.Warnf("[Start] URL query error for %s: %v. Stream marked as offline.", channel.ChannelName, queryErr)
-		return false, queryErr // Return the queryErr so checkStreams can log it
+		return startOffline, queryErr // Return the queryErr so checkStreams can log it
 	}
 	if url == "" {
+		setStreamInfo(false)
 		log.Infof("[Start] No url found for channel: %s. Stream marked as offline.", channel.ChannelName)
-		return false, nil // Not an error, just stream is offline
+		return startOffline, nil // Not an error, just stream is offline
 	}
 
+	// Broadcasts which don't match the capture rules of the channel are not captured, nor are snapshots taken.
+	if reason := evaluateRules(channel.CaptureRules, stream); reason != "" {
+		setStreamInfo(false)
+		skipBroadcast(channel, reason)
+		return startSkipped, nil
+	}
+	acceptBroadcast(id)
+	setStreamInfo(true)
+
 	log.Infof("[Start] Initiating stream capture for '%s' at '%s'", channel.ChannelName, url)
 
 	go func() {
 		// This helpers.ExtractFirstFrame is for the live snapshot.
 		// Ensure it uses absolute paths internally for ffmpeg.
-		if errSnapshot := helpers.ExtractFirstFrame(url, conf.FrameWidth, filepath.Join(channel.ChannelName.AbsoluteChannelDataPath(), database.SnapshotFilename)); errSnapshot != nil {
+		if errSnapshot := liveSnapshot(channel.ChannelName, url, channel.CapturesAudioOnly(), stream.InputArgs()); errSnapshot != nil {
 			log.Errorf("[Start] Error extracting live snapshot for %s: %v", channel.ChannelName, errSnapshot)
 		}
 	}()
 
 	go func() {
 		log.Infof("[Start] Goroutine launched to capture channel %s (ID: %d), URL: %s", channel.ChannelName, id, url)
-		if errCap := CaptureChannel(id, url, channel.SkipStart); errCap != nil {
+		capture := CaptureChannel
+		if channel.Type == database.ChannelTypeRadio {
+			capture = CaptureRadio
+		}
+		errCap := capture(id, stream, channel.SkipStart)
+		if errCap != nil {
 			log.Errorf("[Start] CaptureChannel for %s (ID: %d) returned error: %v", channel.ChannelName, id, errCap)
 		}
+		// A stalled capture is restarted with a freshly resolved URL, unless it has been stopped in the meantime.
+		restart := errors.Is(errCap, errCaptureStalled) && !IsTerminating(id) && IsRecorderActive()
+
 		// DeleteStreamData is crucial for cleanup after CaptureChannel completes or errors.
 		// This ensures that IsRecordingStream will return false for this ID afterwards.
 		DeleteStreamData(id)
 		log.Infof("[Start] Goroutine for channel %s (ID: %d) finished, associated stream data deleted.", channel.ChannelName, id)
+
+		if restart {
+			log.Infof("[Start] Restarting stalled capture of channel %s (ID: %d)", channel.ChannelName, id)
+			if _, errRestart := Start(id); errRestart != nil {
+				log.Errorf("[Start] Error restarting channel %s (ID: %d): %v", channel.ChannelName, id, errRestart)
+			}
+		}
 	}()
 
-	return true, nil // Successfully initiated the start process
+	return startStarted, nil // Successfully initiated the start process
 }
 
 func TerminateAll() {
@@ -380,6 +951,7 @@ func DeleteStreamData(id database.ChannelID) {
 		log.Debugf("[DeleteStreamData] Removing stream command entry for channel ID %d (process was nil or not started).", id)
 	}
 	delete(streams, id)
+	delete(parts, id)
 	delete(recInfo, id)
 	activeRecLock.Unlock()
 
@@ -402,6 +974,7 @@ func ProcessList() []*ProcessInfo {
 		pid  int
 		path string
 		args []string
+		part *capturePart
 	}
 	snapshot := make([]cmdDetail, 0, len(streams))
 	for id, cmd := range streams {
@@ -409,21 +982,24 @@ func ProcessList() []*ProcessInfo {
 		if cmd.Process != nil {
 			pidVal = cmd.Process.Pid
 		}
-		snapshot = append(snapshot, cmdDetail{id: id, pid: pidVal, path: cmd.Path, args: cmd.Args})
+		snapshot = append(snapshot, cmdDetail{id: id, pid: pidVal, path: cmd.Path, args: cmd.Args, part: parts[id]})
 	}
 	activeRecLock.Unlock()
 
 	infoList := make([]*ProcessInfo, 0, len(snapshot))
 	for _, detail := range snapshot {
-		// CombinedOutput cannot be used on an already started/piped command.
-		// Output field will be empty unless captured differently.
-		infoList = append(infoList, &ProcessInfo{
-			ID:     detail.id,
-			Pid:    detail.pid,
-			Path:   detail.path,                    // This is the command path, e.g., "/usr/local/bin/ffmpeg"
-			Args:   strings.Join(detail.args, " "), // This includes the command itself as Args[0]
-			Output: "",                             // Output needs to be captured during the command's run
-		})
+		info := &ProcessInfo{
+			ID:   detail.id,
+			Pid:  detail.pid,
+			Path: detail.path,                    // This is the command path, e.g., "/usr/local/bin/ffmpeg"
+			Args: strings.Join(detail.args, " "), // This includes the command itself as Args[0]
+		}
+		if detail.part != nil {
+			progress := detail.part.currentProgress()
+			info.Output = detail.part.stderr.String()
+			info.Progress = &progress
+		}
+		infoList = append(infoList, info)
 	}
 	return infoList
 }
@@ -459,6 +1035,15 @@ func startThumbnailWorker(ctx context.Context) {
 			}
 			for channelID, infoCopy := range infosToSnapshot { // Iterate over the copied structs
 				log.Debugf("[startThumbnailWorker] Attempting live snapshot for channel %s (ID: %d)", infoCopy.ChannelName, channelID)
+				// A published stream can't be requested twice, the snapshot is taken from the live playlist of the capture.
+				if infoCopy.Ingest {
+					recording := Info(channelID)
+					if recording == nil {
+						continue
+					}
+					infoCopy.URL = filepath.Join(recording.LiveFolder(), helpers.HLSPlaylistFilename)
+					infoCopy.InputArgs = []string{"-live_start_index", "-1"}
+				}
 				// infoCopy is a copy, so Screenshot() operates on its fields safely.
 				if err := infoCopy.Screenshot(); err != nil {
 					log.Errorf("[startThumbnailWorker] Error extracting live snapshot for channel %s (ID: %d): %v", infoCopy.ChannelName, channelID, err)
@@ -0,0 +1,78 @@
+package services
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srad/mediasink/database"
+)
+
+func TestCapturePartProgress(t *testing.T) {
+	part := &capturePart{lastPacketAt: time.Now().Add(-time.Minute)}
+	channel := &database.Channel{StallTimeout: 30}
+
+	if !part.isStalled(channel) {
+		t.Error("part without progress should be stalled")
+	}
+
+	part.readProgress(strings.NewReader("fps=25.0\ntotal_size=1024\nout_time_us=2000000\nprogress=continue\n"))
+
+	if part.totalSize != 1024 || part.outTime != 2000000 {
+		t.Errorf("progress is %d bytes/%dus but should be 1024 bytes/2000000us", part.totalSize, part.outTime)
+	}
+	if part.isStalled(channel) {
+		t.Error("part should not be stalled after it made progress")
+	}
+
+	// The same values again, i.e. no packets arrived.
+	part.lastPacketAt = time.Now().Add(-time.Minute)
+	part.readProgress(strings.NewReader("total_size=1024\nout_time_us=2000000\nprogress=continue\n"))
+	if !part.isStalled(channel) {
+		t.Error("part without new data should be stalled")
+	}
+
+	if part.isStalled(&database.Channel{StallTimeout: 30, DisableStallWatchdog: true}) {
+		t.Error("disabled watchdog should not report a stall")
+	}
+
+	// Channels without a stall timeout use the default.
+	part.lastPacketAt = time.Now().Add(-defaultStallTimeout)
+	if !part.isStalled(&database.Channel{}) {
+		t.Error("stall timeout 0 should apply the default timeout")
+	}
+	part.lastPacketAt = time.Now()
+	if part.isStalled(&database.Channel{}) {
+		t.Error("part should not be stalled within the default timeout")
+	}
+}
+
+func TestRolloverBackoff(t *testing.T) {
+	var backoff rolloverBackoff
+	now := time.Now()
+
+	if !backoff.ready(now) {
+		t.Fatal("first rollover must not be delayed")
+	}
+
+	for i, want := range []time.Duration{rolloverRetryDelay, 2 * rolloverRetryDelay, 4 * rolloverRetryDelay} {
+		if delay := backoff.failed(now); delay != want {
+			t.Errorf("delay after %d failures is %s, want %s", i+1, delay, want)
+		}
+	}
+	if backoff.ready(now) || !backoff.ready(now.Add(4*rolloverRetryDelay)) {
+		t.Error("rollover must wait for the delay")
+	}
+
+	for range 20 {
+		backoff.failed(now)
+	}
+	if delay := backoff.failed(now); delay != maxRolloverDelay {
+		t.Errorf("delay is %s, want the upper limit %s", delay, maxRolloverDelay)
+	}
+
+	backoff.reset()
+	if !backoff.ready(now) {
+		t.Error("rollover must not be delayed after a successful one")
+	}
+}